@@ -87,7 +87,8 @@ func bind(cfg *appConfig, appInfo *jsendx.AppInfo, mtr instr.Metrics) bootstrap.
 			httpserver.WithServerAddr(cfg.PublicAddress),
 			httpserver.WithInstrumentHandler(m.InstrumentHandler),
 			httpserver.WithTraceIDHeaderName(traceid.DefaultHeader),
-			httpserver.WithEnableDefaultRoutes(httpserver.PingRoute),
+			httpserver.WithEnableDefaultRoutes(httpserver.PingRoute, httpserver.OpenAPIRoute),
+			httpserver.WithOpenAPIInfo(appInfo.ProgramName, appInfo.ProgramVersion, "Public API"),
 		}
 
 		if err := httpserver.Start(ctx, serviceBinder, httpPublicOpts...); err != nil {
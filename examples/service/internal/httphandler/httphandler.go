@@ -39,6 +39,10 @@ func (h *HTTPHandler) BindHTTP(_ context.Context) []route.Route {
 			Path:        "/uid",
 			Handler:     h.handleGenUID,
 			Description: "Generates a random UID",
+			Responses: map[int]route.Response{
+				// the UID is sent as a JSON string (see handleGenUID)
+				http.StatusOK: {Description: "Random UID", Body: "", ContentType: httputil.MimeApplicationJSON},
+			},
 		},
 	}
 }
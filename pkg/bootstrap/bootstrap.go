@@ -8,8 +8,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 )
 
 // Bootstrap is the function in charge of configuring the core components
@@ -35,40 +39,98 @@ func Bootstrap(bindFn BindFunc, opts ...Option) error {
 		return fmt.Errorf("error creating application logger: %w", err)
 	}
 
+	cr := &crashReporter{metrics: m, notify: cfg.crashNotifyFunc}
+
+	if cfg.crashNotifyFunc != nil {
+		l = l.WithOptions(zap.WithFatalHook(newFatalHook(cr, l)))
+	}
+
 	l = logging.WithLevelFunctionHook(l, m.IncLogLevelCounter)
 	ctx = logging.WithLogger(ctx, l)
+	ctx = withCrashReporter(ctx, cr)
 
 	defer logging.Sync(l)
 
+	if err := run(ctx, cancel, cfg, bindFn, l, m); err != nil {
+		cr.notifyCrash(l, "bootstrap", err)
+		return err
+	}
+
+	return nil
+}
+
+// run binds and starts the application components, and waits for the shutdown.
+func run(ctx context.Context, cancel context.CancelFunc, cfg *config, bindFn BindFunc, l *zap.Logger, m metrics.Client) error {
 	l.Info("binding application components")
 
 	if err := bindFn(ctx, l, m); err != nil {
 		return fmt.Errorf("application bootstrap error: %w", err)
 	}
 
+	l.Info("starting application components")
+
+	if err := cfg.lifecycle.Start(ctx); err != nil {
+		return fmt.Errorf("application start error: %w", err)
+	}
+
+	cfg.readiness.SetReady(true)
+
 	l.Info("application started")
 
-	done := make(chan struct{})
+	handleSignals(ctx, cfg.signalHandlers)
+
+	waitShutdown(ctx, cfg, l)
 
+	// cancel the application context
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(logging.WithLogger(context.Background(), l), cfg.shutdownTimeout)
+	defer cancelShutdown()
+
+	err := multierr.Append(cfg.lifecycle.failure(), cfg.lifecycle.Stop(shutdownCtx))
+
+	l.Info("application stopped")
+
+	if err != nil {
+		return fmt.Errorf("application shutdown error: %w", err)
+	}
+
+	return nil
+}
+
+// waitShutdown blocks until a shutdown is requested, then sets the application as not ready
+// and waits for the drain delay to let the load balancers stop sending traffic.
+func waitShutdown(ctx context.Context, cfg *config, l *zap.Logger) {
 	// handle shutdown signals
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
-	go func() {
-		defer close(done)
-
-		select {
-		case <-quit: // quit on user signal
-		case <-ctx.Done(): // context canceled
-		}
+	defer signal.Stop(quit)
 
-		// cancel the application context
+	select {
+	case <-quit: // quit on user signal
 		l.Debug("shutdown signal received")
-		cancel()
-	}()
+	case <-ctx.Done(): // context canceled
+		l.Debug("application context canceled")
+	case <-cfg.lifecycle.failed(): // component failure
+		l.Error("component failure", zap.Error(cfg.lifecycle.failure()))
+	}
 
-	<-done
-	l.Info("application stopped")
+	cfg.readiness.SetReady(false)
 
-	return nil
+	if cfg.shutdownDrainDelay <= 0 {
+		return
+	}
+
+	l.Info("draining application traffic", zap.Duration("delay", cfg.shutdownDrainDelay))
+
+	timer := time.NewTimer(cfg.shutdownDrainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C: // drain completed
+	case <-quit: // skip the drain on a second user signal
+		l.Debug("second shutdown signal received")
+	case <-ctx.Done(): // context canceled
+	}
 }
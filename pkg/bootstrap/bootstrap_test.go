@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
 	"github.com/nexmoinc/gosrvlib/pkg/metrics"
 	"github.com/nexmoinc/gosrvlib/pkg/metrics/prometheus"
@@ -57,6 +58,74 @@ func TestBootstrap(t *testing.T) {
 			stopAfter: 500 * time.Millisecond,
 			wantErr:   false,
 		},
+		{
+			name: "should fail and notify the crash due to bind function",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return fmt.Errorf("bind error")
+			},
+			opts: []Option{
+				WithCrashNotifyFunc(func(ctx context.Context, name string, err error) {
+					require.Equal(t, "bootstrap", name)
+					require.Error(t, err)
+				}),
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail due to component start error",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return nil
+			},
+			opts: []Option{
+				WithLifecycle(testLifecycle(t, fmt.Errorf("start error"), nil, nil)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "should fail due to component stop error",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return nil
+			},
+			opts: []Option{
+				WithLifecycle(testLifecycle(t, nil, fmt.Errorf("stop error"), nil)),
+			},
+			stopAfter: 500 * time.Millisecond,
+			wantErr:   true,
+		},
+		{
+			name: "should fail and shutdown due to component failure",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return nil
+			},
+			opts: []Option{
+				WithLifecycle(testLifecycle(t, nil, nil, fmt.Errorf("async error"))),
+			},
+			wantErr: true,
+		},
+		{
+			name: "should succeed and stop components with context cancel",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return nil
+			},
+			opts: []Option{
+				WithLifecycle(testLifecycle(t, nil, nil, nil)),
+				WithShutdownTimeout(time.Second),
+			},
+			stopAfter: 500 * time.Millisecond,
+			wantErr:   false,
+		},
+		{
+			name: "should succeed and drain traffic before exit with SIGTERM",
+			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
+				return nil
+			},
+			opts: []Option{
+				WithShutdownDrainDelay(100 * time.Millisecond),
+			},
+			stopAfter: 500 * time.Millisecond,
+			sigterm:   true,
+			wantErr:   false,
+		},
 		{
 			name: "should succeed and exit with SIGTERM",
 			bindFunc: func(context.Context, *zap.Logger, metrics.Client) error {
@@ -124,3 +193,57 @@ func TestBootstrap(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest
+func TestBootstrap_readiness(t *testing.T) {
+	// cannot run in parallel because signals are received by all parallel tests
+
+	readiness := healthcheck.NewReadiness()
+
+	bindFn := func(context.Context, *zap.Logger, metrics.Client) error {
+		require.False(t, readiness.IsReady(), "the application should not be ready during warm-up")
+		return nil
+	}
+
+	checkReady := make(chan bool, 1)
+
+	time.AfterFunc(200*time.Millisecond, func() {
+		checkReady <- readiness.IsReady()
+
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	})
+
+	err := Bootstrap(
+		bindFn,
+		WithContext(testutil.Context()),
+		WithLogger(zap.NewNop()),
+		WithReadiness(readiness),
+		WithShutdownDrainDelay(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.True(t, <-checkReady, "the application should be ready after start")
+	require.False(t, readiness.IsReady(), "the application should not be ready after shutdown")
+}
+
+func testLifecycle(t *testing.T, startErr, stopErr, failErr error) *Lifecycle {
+	t.Helper()
+
+	lc := NewLifecycle()
+
+	start := func(ctx context.Context) error {
+		if failErr != nil {
+			time.AfterFunc(100*time.Millisecond, func() { lc.Fail("test", failErr) })
+		}
+
+		return startErr
+	}
+
+	stop := func(ctx context.Context) error {
+		return stopErr
+	}
+
+	err := lc.Register("test", NewComponent(start, stop))
+	require.NoError(t, err)
+
+	return lc
+}
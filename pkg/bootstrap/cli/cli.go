@@ -0,0 +1,284 @@
+// Package cli provides a minimal command-line layer for applications built with the bootstrap package.
+// All commands share the same configuration loading, logger and metrics creation.
+//
+// The command line format is:
+//
+//	program [global flags] [command] [command flags] [arguments]
+//
+// The global flags are:
+//
+//	-c, --configDir  Configuration directory to be added on top of the search list
+//	-f, --logFormat  Logging format: CONSOLE, JSON
+//	-o, --logLevel   Log level: EMERGENCY, ALERT, CRITICAL, ERROR, WARNING, NOTICE, INFO, DEBUG
+//
+// When no command is specified, the "serve" command is executed if enabled, otherwise the "help" command.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// CommandFunc is a type alias for the function executed by a command.
+type CommandFunc func(ctx context.Context, env *Env, args []string) error
+
+// Command describes a CLI sub-command.
+type Command struct {
+	// Name is the command name used on the command line.
+	Name string
+
+	// Description is the short description displayed by the help command.
+	Description string
+
+	// Flags is an optional function to define the command-specific flags.
+	Flags func(fs *pflag.FlagSet)
+
+	// SkipSetup disables the configuration loading and the logger and metrics creation.
+	SkipSetup bool
+
+	// Run is the command function.
+	Run CommandFunc
+}
+
+// Env contains the shared resources available to the commands.
+type Env struct {
+	// AppName is the application name.
+	AppName string
+
+	// Version is the application version.
+	Version string
+
+	// Release is the application release.
+	Release string
+
+	// ConfigDir is the configuration directory specified on the command line.
+	ConfigDir string
+
+	// Config is the loaded and validated application configuration.
+	Config config.Configuration
+
+	// Logger is the application logger.
+	Logger *zap.Logger
+
+	// Metrics is the application metrics client.
+	Metrics metrics.Client
+
+	// Stdout is the writer for the command output.
+	Stdout io.Writer
+}
+
+// CLI is the command-line entry point of the application.
+type CLI struct {
+	appName          string
+	version          string
+	release          string
+	envPrefix        string
+	newConfig        bootstrap.NewConfigFunc
+	createMetrics    bootstrap.CreateMetricsClientFunc
+	loggerOpts       []logging.Option
+	healthCheckURLFn HealthCheckURLFunc
+	commands         map[string]*Command
+	stdout           io.Writer
+}
+
+// New creates a new CLI with the built-in "check-config", "healthcheck", "help" and "version" commands.
+// The "serve" and "migrate" commands are enabled with the WithServe and WithMigrate options.
+func New(appName, version, release, envPrefix string, newConfig bootstrap.NewConfigFunc, opts ...Option) (*CLI, error) {
+	c := &CLI{
+		appName:       appName,
+		version:       version,
+		release:       release,
+		envPrefix:     envPrefix,
+		newConfig:     newConfig,
+		createMetrics: defaultCreateMetrics,
+		commands:      make(map[string]*Command),
+		stdout:        os.Stdout,
+	}
+
+	builtins := []Command{
+		c.checkConfigCommand(),
+		c.healthCheckCommand(),
+		c.helpCommand(),
+		c.versionCommand(),
+	}
+
+	for _, cmd := range builtins {
+		if err := c.AddCommand(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, applyOpt := range opts {
+		if err := applyOpt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// AddCommand registers a custom command or replaces a built-in one.
+func (c *CLI) AddCommand(cmd Command) error {
+	if cmd.Name == "" {
+		return fmt.Errorf("the command name is required")
+	}
+
+	if cmd.Run == nil {
+		return fmt.Errorf("the command %q requires a Run function", cmd.Name)
+	}
+
+	c.commands[cmd.Name] = &cmd
+
+	return nil
+}
+
+// Run parses the command line arguments (without the program name) and executes the selected command.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	var (
+		configDir string
+		logFormat string
+		logLevel  string
+	)
+
+	gfs := pflag.NewFlagSet(c.appName, pflag.ContinueOnError)
+	gfs.SetInterspersed(false)
+	gfs.SetOutput(c.stdout)
+	gfs.StringVarP(&configDir, "configDir", "c", "", "Configuration directory to be added on top of the search list")
+	gfs.StringVarP(&logFormat, "logFormat", "f", "", "Logging format: CONSOLE, JSON")
+	gfs.StringVarP(&logLevel, "logLevel", "o", "", "Log level: EMERGENCY, ALERT, CRITICAL, ERROR, WARNING, NOTICE, INFO, DEBUG")
+
+	if err := gfs.Parse(args); err != nil {
+		return fmt.Errorf("failed parsing the global flags: %w", err)
+	}
+
+	name := cmdServe
+	if _, ok := c.commands[cmdServe]; !ok {
+		name = cmdHelp
+	}
+
+	cmdArgs := gfs.Args()
+
+	if len(cmdArgs) > 0 {
+		name = cmdArgs[0]
+		cmdArgs = cmdArgs[1:]
+	}
+
+	cmd, ok := c.commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q, run \"%s %s\" for usage", name, c.appName, cmdHelp)
+	}
+
+	fs := pflag.NewFlagSet(c.appName+" "+cmd.Name, pflag.ContinueOnError)
+	fs.SetOutput(c.stdout)
+
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+
+	if err := fs.Parse(cmdArgs); err != nil {
+		return fmt.Errorf("failed parsing the %q command flags: %w", cmd.Name, err)
+	}
+
+	env := &Env{
+		AppName:   c.appName,
+		Version:   c.version,
+		Release:   c.release,
+		ConfigDir: configDir,
+		Stdout:    c.stdout,
+	}
+
+	if !cmd.SkipSetup {
+		if err := c.setup(env, logFormat, logLevel); err != nil {
+			return err
+		}
+
+		defer logging.Sync(env.Logger)
+	}
+
+	return cmd.Run(ctx, env, fs.Args())
+}
+
+// setup loads the configuration and creates the logger and the metrics client.
+func (c *CLI) setup(env *Env, logFormat, logLevel string) error {
+	cfg := c.newConfig()
+
+	if err := config.Load(c.appName, env.ConfigDir, c.envPrefix, cfg); err != nil {
+		return fmt.Errorf("failed loading config: %w", err)
+	}
+
+	loggerOpts := []logging.Option{
+		logging.WithFields(
+			zap.String("program", c.appName),
+			zap.String("version", c.version),
+			zap.String("release", c.release),
+		),
+	}
+
+	if lc, ok := cfg.(logConfigurer); ok {
+		if logFormat != "" {
+			lc.LogConfig().Format = logFormat
+		}
+
+		if logLevel != "" {
+			lc.LogConfig().Level = logLevel
+		}
+
+		logFormat = lc.LogConfig().Format
+		logLevel = lc.LogConfig().Level
+	}
+
+	if logFormat != "" {
+		loggerOpts = append(loggerOpts, logging.WithFormatStr(logFormat))
+	}
+
+	if logLevel != "" {
+		loggerOpts = append(loggerOpts, logging.WithLevelStr(logLevel))
+	}
+
+	l, err := logging.NewLogger(append(loggerOpts, c.loggerOpts...)...)
+	if err != nil {
+		return fmt.Errorf("failed configuring logger: %w", err)
+	}
+
+	m, err := c.createMetrics()
+	if err != nil {
+		return fmt.Errorf("failed creating metrics client: %w", err)
+	}
+
+	env.Config = cfg
+	env.Logger = l
+	env.Metrics = m
+
+	return nil
+}
+
+// commandNames returns the sorted list of the registered command names.
+func (c *CLI) commandNames() []string {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// logConfigurer is implemented by the application configurations embedding config.BaseConfig.
+type logConfigurer interface {
+	LogConfig() *config.LogConfig
+}
+
+func defaultCreateMetrics() (metrics.Client, error) {
+	return &metrics.Default{}, nil
+}
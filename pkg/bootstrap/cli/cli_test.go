@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testAppConfig struct {
+	config.BaseConfig `mapstructure:",squash"`
+	Name              string `mapstructure:"name"`
+}
+
+func (c *testAppConfig) SetDefaults(v config.Viper) {
+	v.SetDefault("name", "default")
+}
+
+func (c *testAppConfig) Validate() error {
+	if c.Name == "invalid" {
+		return fmt.Errorf("invalid name")
+	}
+
+	return nil
+}
+
+func newTestConfig() config.Configuration {
+	return &testAppConfig{}
+}
+
+func testConfigDir(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o600)
+	require.NoError(t, err)
+
+	return dir
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	c, err := New("test", "1.2.3", "4", "TEST", newTestConfig)
+	require.NoError(t, err)
+	require.Equal(t, []string{cmdCheckConfig, cmdHealthCheck, cmdHelp, cmdVersion}, c.commandNames())
+
+	_, err = New("test", "1.2.3", "4", "TEST", newTestConfig, WithCommand(Command{}))
+	require.Error(t, err)
+}
+
+func TestCLI_AddCommand(t *testing.T) {
+	t.Parallel()
+
+	c, err := New("test", "1.2.3", "4", "TEST", newTestConfig)
+	require.NoError(t, err)
+
+	require.Error(t, c.AddCommand(Command{}))
+	require.Error(t, c.AddCommand(Command{Name: "custom"}))
+
+	fn := func(context.Context, *Env, []string) error { return nil }
+	require.NoError(t, c.AddCommand(Command{Name: "custom", Run: fn}))
+	require.Contains(t, c.commandNames(), "custom")
+}
+
+//nolint:gocognit
+func TestCLI_Run(t *testing.T) {
+	t.Parallel()
+
+	validConfig := `{"name":"alpha","log":{"format":"JSON","level":"INFO"}}`
+
+	tests := []struct {
+		name       string
+		config     string
+		opts       []Option
+		args       []string
+		wantErr    bool
+		wantOutput string
+	}{
+		{
+			name:    "invalid global flag",
+			args:    []string{"--invalid"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"unknown"},
+			wantErr: true,
+		},
+		{
+			name:       "default help command when serve is not enabled",
+			wantOutput: "Usage: test [global flags] [command] [command flags]\n",
+		},
+		{
+			name:    "invalid command flag",
+			args:    []string{"version", "--invalid"},
+			wantErr: true,
+		},
+		{
+			name:       "version",
+			args:       []string{"version"},
+			wantOutput: "1.2.3\n",
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantOutput: "  version        Print this program version\n",
+		},
+		{
+			name:       "check-config",
+			config:     validConfig,
+			args:       []string{"check-config"},
+			wantOutput: "configuration OK\n",
+		},
+		{
+			name:    "check-config with invalid configuration",
+			config:  `{"name":"invalid"}`,
+			args:    []string{"check-config"},
+			wantErr: true,
+		},
+		{
+			name:    "check-config with invalid log level flag",
+			config:  validConfig,
+			args:    []string{"--logLevel", "invalid", "check-config"},
+			wantErr: true,
+		},
+		{
+			name:   "check-config with metrics error",
+			config: validConfig,
+			opts: []Option{
+				WithCreateMetricsClientFunc(func() (metrics.Client, error) {
+					return nil, fmt.Errorf("metrics error")
+				}),
+			},
+			args:    []string{"check-config"},
+			wantErr: true,
+		},
+		{
+			name:   "custom command",
+			config: validConfig,
+			opts: []Option{
+				WithCommand(Command{
+					Name: "custom",
+					Flags: func(fs *pflag.FlagSet) {
+						fs.String("value", "", "test value")
+					},
+					Run: func(_ context.Context, env *Env, args []string) error {
+						_, err := fmt.Fprintf(env.Stdout, "%s %v", env.Config.(*testAppConfig).Name, args) //nolint:forcetypeassert
+						return err                                                                         //nolint:wrapcheck
+					},
+				}),
+			},
+			args:       []string{"--logFormat", "CONSOLE", "--logLevel", "DEBUG", "custom", "--value", "x", "arg1"},
+			wantOutput: "alpha [arg1]",
+		},
+		{
+			name:   "migrate",
+			config: validConfig,
+			opts: []Option{
+				WithMigrate(func(_ context.Context, env *Env, _ []string) error {
+					return fmt.Errorf("migration error")
+				}),
+			},
+			args:    []string{"migrate"},
+			wantErr: true,
+		},
+		{
+			name:   "serve",
+			config: validConfig,
+			opts: []Option{
+				WithServe(func(cfg config.Configuration) bootstrap.BindFunc {
+					return func(ctx context.Context, l *zap.Logger, m metrics.Client) error {
+						return fmt.Errorf("bind error")
+					}
+				}),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			opts := append([]Option{WithOutput(&out)}, tt.opts...)
+
+			c, err := New("test", "1.2.3", "4", "TEST", newTestConfig, opts...)
+			require.NoError(t, err)
+
+			args := tt.args
+			if tt.config != "" {
+				args = append([]string{"--configDir", testConfigDir(t, tt.config)}, args...)
+			}
+
+			err = c.Run(testutil.Context(), args)
+			require.Equal(t, tt.wantErr, err != nil, "Run() error = %v, wantErr %v", err, tt.wantErr)
+
+			if tt.wantOutput != "" {
+				require.Contains(t, out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
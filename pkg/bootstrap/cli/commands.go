@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/spf13/pflag"
+)
+
+const (
+	cmdCheckConfig = "check-config"
+	cmdHealthCheck = "healthcheck"
+	cmdHelp        = "help"
+	cmdMigrate     = "migrate"
+	cmdServe       = "serve"
+	cmdVersion     = "version"
+
+	defaultHealthCheckTimeout = 5 * time.Second
+)
+
+// ServeFunc returns the bootstrap bind function for the loaded application configuration.
+type ServeFunc func(cfg config.Configuration) bootstrap.BindFunc
+
+// HealthCheckURLFunc returns the URL of the status endpoint of the running instance
+// for the loaded application configuration.
+type HealthCheckURLFunc func(cfg config.Configuration) string
+
+// serveCommand returns the command that runs the application via bootstrap.Bootstrap.
+func serveCommand(fn ServeFunc, opts []bootstrap.Option) Command {
+	return Command{
+		Name:        cmdServe,
+		Description: "Run the application service (default command)",
+		Run: func(ctx context.Context, env *Env, _ []string) error {
+			createMetrics := func() (metrics.Client, error) {
+				return env.Metrics, nil
+			}
+
+			bopts := []bootstrap.Option{
+				bootstrap.WithContext(ctx),
+				bootstrap.WithLogger(env.Logger),
+				bootstrap.WithCreateMetricsClientFunc(createMetrics),
+			}
+
+			return bootstrap.Bootstrap(fn(env.Config), append(bopts, opts...)...) //nolint:wrapcheck
+		},
+	}
+}
+
+// migrateCommand returns the command that runs the application migrations.
+func migrateCommand(fn CommandFunc) Command {
+	return Command{
+		Name:        cmdMigrate,
+		Description: "Run the application migrations (e.g. database schema changes)",
+		Run:         fn,
+	}
+}
+
+func (c *CLI) checkConfigCommand() Command {
+	return Command{
+		Name:        cmdCheckConfig,
+		Description: "Load and validate the configuration",
+		Run: func(_ context.Context, env *Env, _ []string) error {
+			_, err := fmt.Fprintln(env.Stdout, "configuration OK")
+			return err //nolint:wrapcheck
+		},
+	}
+}
+
+func (c *CLI) healthCheckCommand() Command {
+	var (
+		url     string
+		timeout time.Duration
+	)
+
+	return Command{
+		Name:        cmdHealthCheck,
+		Description: "Check the status endpoint of the running instance (e.g. for Docker HEALTHCHECK)",
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&url, "url", "u", "", "URL of the status endpoint")
+			fs.DurationVarP(&timeout, "timeout", "t", defaultHealthCheckTimeout, "Request timeout")
+		},
+		Run: func(ctx context.Context, env *Env, _ []string) error {
+			statusURL := url
+			if statusURL == "" && c.healthCheckURLFn != nil {
+				statusURL = c.healthCheckURLFn(env.Config)
+			}
+
+			if statusURL == "" {
+				return fmt.Errorf("the status endpoint URL is required")
+			}
+
+			if err := healthcheck.CheckHTTPStatus(ctx, &http.Client{}, http.MethodGet, statusURL, http.StatusOK, timeout); err != nil {
+				return fmt.Errorf("healthcheck failed: %w", err)
+			}
+
+			_, err := fmt.Fprintln(env.Stdout, healthcheck.StatusOK)
+
+			return err //nolint:wrapcheck
+		},
+	}
+}
+
+func (c *CLI) helpCommand() Command {
+	return Command{
+		Name:        cmdHelp,
+		Description: "Print the list of available commands",
+		SkipSetup:   true,
+		Run: func(_ context.Context, env *Env, _ []string) error {
+			if _, err := fmt.Fprintf(env.Stdout, "Usage: %s [global flags] [command] [command flags]\n\nCommands:\n", c.appName); err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			for _, name := range c.commandNames() {
+				if _, err := fmt.Fprintf(env.Stdout, "  %-14s %s\n", name, c.commands[name].Description); err != nil {
+					return err //nolint:wrapcheck
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func (c *CLI) versionCommand() Command {
+	return Command{
+		Name:        cmdVersion,
+		Description: "Print this program version",
+		SkipSetup:   true,
+		Run: func(_ context.Context, env *Env, _ []string) error {
+			_, err := fmt.Fprintln(env.Stdout, env.Version)
+			return err //nolint:wrapcheck
+		},
+	}
+}
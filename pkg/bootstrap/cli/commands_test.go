@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_serveCommand(t *testing.T) {
+	t.Parallel()
+
+	var bound bool
+
+	fn := func(cfg config.Configuration) bootstrap.BindFunc {
+		return func(ctx context.Context, l *zap.Logger, m metrics.Client) error {
+			bound = cfg.(*testAppConfig).Name == "alpha" //nolint:forcetypeassert
+			return nil
+		}
+	}
+
+	env := &Env{
+		Config:  &testAppConfig{Name: "alpha"},
+		Logger:  zap.NewNop(),
+		Metrics: &metrics.Default{},
+	}
+
+	ctx, cancel := context.WithTimeout(testutil.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	cmd := serveCommand(fn, []bootstrap.Option{bootstrap.WithShutdownTimeout(time.Second)})
+	require.Equal(t, cmdServe, cmd.Name)
+
+	err := cmd.Run(ctx, env, nil)
+	require.NoError(t, err)
+	require.True(t, bound)
+}
+
+func TestCLI_healthCheckCommand(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name       string
+		urlFn      HealthCheckURLFunc
+		args       []string
+		wantErr    bool
+		wantOutput string
+	}{
+		{
+			name:    "missing URL",
+			wantErr: true,
+		},
+		{
+			name:       "success with URL flag",
+			args:       []string{"--url", server.URL + "/status"},
+			wantOutput: "OK\n",
+		},
+		{
+			name: "success with URL function",
+			urlFn: func(cfg config.Configuration) string {
+				return server.URL + "/status"
+			},
+			wantOutput: "OK\n",
+		},
+		{
+			name:    "unavailable",
+			args:    []string{"-u", server.URL + "/error", "-t", "1s"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			c, err := New("test", "1.2.3", "4", "TEST", newTestConfig, WithOutput(&out), WithHealthCheckURLFunc(tt.urlFn))
+			require.NoError(t, err)
+
+			args := append([]string{"--configDir", testConfigDir(t, `{}`), cmdHealthCheck}, tt.args...)
+
+			err = c.Run(testutil.Context(), args)
+			require.Equal(t, tt.wantErr, err != nil, "Run() error = %v, wantErr %v", err, tt.wantErr)
+			require.Equal(t, tt.wantOutput, out.String())
+		})
+	}
+}
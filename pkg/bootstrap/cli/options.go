@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+)
+
+// Option is a type alias for a function that configures the CLI.
+type Option func(c *CLI) error
+
+// WithServe enables the "serve" command that runs the application via bootstrap.Bootstrap.
+// The CLI sets the context, logger and metrics client, and then applies the additional bootstrap options.
+func WithServe(fn ServeFunc, opts ...bootstrap.Option) Option {
+	return func(c *CLI) error {
+		return c.AddCommand(serveCommand(fn, opts))
+	}
+}
+
+// WithMigrate enables the "migrate" command with the specified migration function.
+func WithMigrate(fn CommandFunc) Option {
+	return func(c *CLI) error {
+		return c.AddCommand(migrateCommand(fn))
+	}
+}
+
+// WithCommand registers a custom command or replaces a built-in one.
+func WithCommand(cmd Command) Option {
+	return func(c *CLI) error {
+		return c.AddCommand(cmd)
+	}
+}
+
+// WithHealthCheckURLFunc sets the function returning the default status endpoint URL used by the "healthcheck" command.
+func WithHealthCheckURLFunc(fn HealthCheckURLFunc) Option {
+	return func(c *CLI) error {
+		c.healthCheckURLFn = fn
+		return nil
+	}
+}
+
+// WithCreateMetricsClientFunc overrides the default metrics client creation function.
+func WithCreateMetricsClientFunc(fn bootstrap.CreateMetricsClientFunc) Option {
+	return func(c *CLI) error {
+		c.createMetrics = fn
+		return nil
+	}
+}
+
+// WithLoggerOptions adds logger options on top of the ones derived from the configuration and the command line.
+func WithLoggerOptions(opts ...logging.Option) Option {
+	return func(c *CLI) error {
+		c.loggerOpts = append(c.loggerOpts, opts...)
+		return nil
+	}
+}
+
+// WithOutput sets the writer for the commands output (default os.Stdout).
+func WithOutput(w io.Writer) Option {
+	return func(c *CLI) error {
+		c.stdout = w
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/bootstrap"
+	"github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestCLI() *CLI {
+	return &CLI{commands: make(map[string]*Command)}
+}
+
+func TestWithServe(t *testing.T) {
+	t.Parallel()
+
+	v := func(cfg config.Configuration) bootstrap.BindFunc { return nil }
+	c := newTestCLI()
+	err := WithServe(v)(c)
+	require.NoError(t, err)
+	require.Contains(t, c.commands, cmdServe)
+}
+
+func TestWithMigrate(t *testing.T) {
+	t.Parallel()
+
+	v := func(context.Context, *Env, []string) error { return nil }
+	c := newTestCLI()
+	err := WithMigrate(v)(c)
+	require.NoError(t, err)
+	require.Contains(t, c.commands, cmdMigrate)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(c.commands[cmdMigrate].Run).Pointer())
+}
+
+func TestWithCommand(t *testing.T) {
+	t.Parallel()
+
+	v := Command{Name: "custom", Run: func(context.Context, *Env, []string) error { return nil }}
+	c := newTestCLI()
+	err := WithCommand(v)(c)
+	require.NoError(t, err)
+	require.Contains(t, c.commands, "custom")
+}
+
+func TestWithHealthCheckURLFunc(t *testing.T) {
+	t.Parallel()
+
+	v := func(cfg config.Configuration) string { return "" }
+	c := newTestCLI()
+	err := WithHealthCheckURLFunc(v)(c)
+	require.NoError(t, err)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(c.healthCheckURLFn).Pointer())
+}
+
+func TestWithCreateMetricsClientFunc(t *testing.T) {
+	t.Parallel()
+
+	v := func() (metrics.Client, error) { return nil, nil }
+	c := newTestCLI()
+	err := WithCreateMetricsClientFunc(v)(c)
+	require.NoError(t, err)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(c.createMetrics).Pointer())
+}
+
+func TestWithLoggerOptions(t *testing.T) {
+	t.Parallel()
+
+	v := []logging.Option{logging.WithLevel(zap.InfoLevel)}
+	c := newTestCLI()
+	err := WithLoggerOptions(v...)(c)
+	require.NoError(t, err)
+	require.Len(t, c.loggerOpts, 1)
+
+	err = WithLoggerOptions(logging.WithFormat(logging.JSONFormat))(c)
+	require.NoError(t, err)
+	require.Len(t, c.loggerOpts, 2)
+}
+
+func TestWithOutput(t *testing.T) {
+	t.Parallel()
+
+	v := &bytes.Buffer{}
+	c := newTestCLI()
+	err := WithOutput(v)(c)
+	require.NoError(t, err)
+	require.Equal(t, v, c.stdout)
+}
@@ -2,7 +2,9 @@ package bootstrap
 
 import (
 	"context"
+	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
 	"github.com/nexmoinc/gosrvlib/pkg/metrics"
 	"go.uber.org/zap"
@@ -21,6 +23,12 @@ type config struct {
 	context                 context.Context
 	createLoggerFunc        CreateLoggerFunc
 	createMetricsClientFunc CreateMetricsClientFunc
+	lifecycle               *Lifecycle
+	shutdownTimeout         time.Duration
+	shutdownDrainDelay      time.Duration
+	readiness               *healthcheck.Readiness
+	signalHandlers          []signalHandler
+	crashNotifyFunc         CrashNotifyFunc
 }
 
 func defaultConfig() *config {
@@ -28,6 +36,9 @@ func defaultConfig() *config {
 		context:                 context.Background(),
 		createLoggerFunc:        defaultCreateLogger,
 		createMetricsClientFunc: defaultCreateMetricsClientFunc,
+		lifecycle:               NewLifecycle(),
+		shutdownTimeout:         30 * time.Second,
+		readiness:               healthcheck.NewReadiness(),
 	}
 }
 
@@ -14,6 +14,9 @@ func Test_defaultConfig(t *testing.T) {
 	require.NotNil(t, cfg.context)
 	require.NotNil(t, cfg.createLoggerFunc)
 	require.NotNil(t, cfg.createMetricsClientFunc)
+	require.NotNil(t, cfg.lifecycle)
+	require.NotZero(t, cfg.shutdownTimeout)
+	require.NotNil(t, cfg.readiness)
 }
 
 func Test_defaultCreateLogger(t *testing.T) {
@@ -0,0 +1,106 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const crashMetricTask = "goroutine"
+
+// CrashNotifyFunc is a type alias for the function used to report an application crash to an external service.
+// For example, a message can be sent to a channel with the slack package client.
+type CrashNotifyFunc func(ctx context.Context, name string, err error)
+
+// GoFunc is a type alias for the function executed in a goroutine by Go.
+type GoFunc func(ctx context.Context) error
+
+type crashCtxKey struct{}
+
+// crashReporter reports errors and crashes via logs, metrics and the optional crash notifier.
+type crashReporter struct {
+	metrics metrics.Client
+	notify  CrashNotifyFunc
+}
+
+// Go runs the function in a new goroutine and recovers from panics.
+// A panic is logged with the stack trace, counted with the metrics IncErrorCounter function,
+// and reported to the crash notifier configured with the bootstrap WithCrashNotifyFunc option.
+// A returned error is logged and counted.
+// The logger, metrics client and notifier are retrieved from the application context passed to the BindFunc.
+func Go(ctx context.Context, name string, fn GoFunc) {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				crashReporterFromContext(ctx).reportPanic(ctx, name, p)
+			}
+		}()
+
+		if err := fn(ctx); err != nil {
+			crashReporterFromContext(ctx).reportError(ctx, name, err)
+		}
+	}()
+}
+
+func withCrashReporter(ctx context.Context, cr *crashReporter) context.Context {
+	return context.WithValue(ctx, crashCtxKey{}, cr)
+}
+
+func crashReporterFromContext(ctx context.Context) *crashReporter {
+	if cr, ok := ctx.Value(crashCtxKey{}).(*crashReporter); ok {
+		return cr
+	}
+
+	return &crashReporter{metrics: &metrics.Default{}}
+}
+
+func (cr *crashReporter) reportPanic(ctx context.Context, name string, p interface{}) {
+	l := logging.FromContext(ctx)
+
+	l.Error("panic",
+		zap.String("goroutine", name),
+		zap.Any("err", p),
+		zap.String("stacktrace", string(debug.Stack())),
+	)
+
+	cr.metrics.IncErrorCounter(crashMetricTask, name, "panic")
+	cr.notifyCrash(l, name, fmt.Errorf("panic: %v", p))
+}
+
+func (cr *crashReporter) reportError(ctx context.Context, name string, err error) {
+	logging.FromContext(ctx).Error("goroutine error", zap.String("goroutine", name), zap.Error(err))
+	cr.metrics.IncErrorCounter(crashMetricTask, name, "error")
+}
+
+// notifyCrash calls the crash notifier (if any) with a new context, as the application context may be already canceled.
+func (cr *crashReporter) notifyCrash(l *zap.Logger, name string, err error) {
+	if cr.notify == nil {
+		return
+	}
+
+	cr.notify(logging.WithLogger(context.Background(), l), name, err)
+}
+
+// fatalHook is a zap hook that notifies the crash before terminating the program on fatal log entries.
+type fatalHook struct {
+	cr   *crashReporter
+	l    *zap.Logger
+	exit func(code int)
+}
+
+func newFatalHook(cr *crashReporter, l *zap.Logger) *fatalHook {
+	return &fatalHook{cr: cr, l: l, exit: os.Exit}
+}
+
+// OnWrite implements the zapcore.CheckWriteHook interface.
+func (h *fatalHook) OnWrite(ce *zapcore.CheckedEntry, _ []zapcore.Field) {
+	h.cr.notifyCrash(h.l, "fatal", errors.New(ce.Message))
+	h.exit(1)
+}
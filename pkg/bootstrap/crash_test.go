@@ -0,0 +1,134 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testCrashMetrics struct {
+	metrics.Default
+	codes chan string
+}
+
+func (m *testCrashMetrics) IncErrorCounter(task, operation, code string) {
+	m.codes <- task + ":" + operation + ":" + code
+}
+
+func TestGo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fn         GoFunc
+		wantCode   string
+		wantNotify bool
+		wantLog    string
+	}{
+		{
+			name: "panic",
+			fn: func(ctx context.Context) error {
+				panic("test panic")
+			},
+			wantCode:   "goroutine:worker:panic",
+			wantNotify: true,
+			wantLog:    "panic",
+		},
+		{
+			name: "error",
+			fn: func(ctx context.Context) error {
+				return fmt.Errorf("test error")
+			},
+			wantCode: "goroutine:worker:error",
+			wantLog:  "goroutine error",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+			m := &testCrashMetrics{codes: make(chan string, 1)}
+			notified := make(chan error, 1)
+			notify := func(ctx context.Context, name string, err error) {
+				require.Equal(t, "worker", name)
+				require.NotNil(t, logging.FromContext(ctx))
+				notified <- err
+			}
+
+			ctx = withCrashReporter(ctx, &crashReporter{metrics: m, notify: notify})
+
+			Go(ctx, "worker", tt.fn)
+
+			select {
+			case code := <-m.codes:
+				require.Equal(t, tt.wantCode, code)
+			case <-time.After(time.Second):
+				t.Fatal("error counter not incremented")
+			}
+
+			if tt.wantNotify {
+				select {
+				case err := <-notified:
+					require.Error(t, err)
+				case <-time.After(time.Second):
+					t.Fatal("crash not notified")
+				}
+			}
+
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			require.Equal(t, tt.wantLog, entries[0].Message)
+			require.Equal(t, "worker", entries[0].ContextMap()["goroutine"])
+		})
+	}
+}
+
+func TestGo_withoutReporter(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+
+	Go(context.Background(), "worker", func(ctx context.Context) error {
+		defer close(done)
+		panic("test panic")
+	})
+
+	<-done
+}
+
+func Test_fatalHook(t *testing.T) {
+	t.Parallel()
+
+	var (
+		notifiedErr error
+		exitCode    int
+	)
+
+	cr := &crashReporter{
+		metrics: &metrics.Default{},
+		notify: func(ctx context.Context, name string, err error) {
+			notifiedErr = err
+		},
+	}
+
+	hook := newFatalHook(cr, zap.NewNop())
+	hook.exit = func(code int) { exitCode = code }
+
+	l := zap.NewNop().WithOptions(zap.WithFatalHook(hook))
+	l.Fatal("fatal error")
+
+	require.EqualError(t, notifiedErr, "fatal error")
+	require.Equal(t, 1, exitCode)
+}
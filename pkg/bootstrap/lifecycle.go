@@ -0,0 +1,238 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Component is the interface of an application component with a managed lifecycle.
+type Component interface {
+	// Start initializes the component. Long-running tasks must be started in the background.
+	Start(ctx context.Context) error
+
+	// Stop gracefully stops the component within the context deadline.
+	Stop(ctx context.Context) error
+}
+
+// LifecycleFunc is a type alias for a component start or stop function.
+type LifecycleFunc func(ctx context.Context) error
+
+// NewComponent returns a Component using the specified start and stop functions.
+// A nil function is treated as a no-operation.
+func NewComponent(start, stop LifecycleFunc) Component {
+	return &funcComponent{start: start, stop: stop}
+}
+
+type funcComponent struct {
+	start LifecycleFunc
+	stop  LifecycleFunc
+}
+
+func (c *funcComponent) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+
+	return c.start(ctx)
+}
+
+func (c *funcComponent) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+
+	return c.stop(ctx)
+}
+
+type lifecycleEntry struct {
+	name      string
+	component Component
+	dependsOn []string
+}
+
+// Lifecycle is a registry of application components.
+// The components are started in dependency order and stopped in reverse order.
+type Lifecycle struct {
+	mu      sync.Mutex // guards the component registry during Register, Start and Stop
+	entries []*lifecycleEntry
+	index   map[string]*lifecycleEntry
+	started []*lifecycleEntry
+
+	// the failures have a dedicated mutex, so Fail can be called by the components from their Start or Stop hooks
+	failMu   sync.Mutex
+	failures []error
+	failCh   chan struct{}
+	failOnce sync.Once
+}
+
+// NewLifecycle creates a new empty component registry.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{
+		index:  make(map[string]*lifecycleEntry),
+		failCh: make(chan struct{}),
+	}
+}
+
+// Register adds a component with a unique name and the list of component names it depends on.
+// The dependencies are always started before the component and stopped after it.
+func (lc *Lifecycle) Register(name string, c Component, dependsOn ...string) error {
+	if name == "" {
+		return fmt.Errorf("the component name is required")
+	}
+
+	if c == nil {
+		return fmt.Errorf("the component %q is nil", name)
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if _, ok := lc.index[name]; ok {
+		return fmt.Errorf("the component %q is already registered", name)
+	}
+
+	e := &lifecycleEntry{
+		name:      name,
+		component: c,
+		dependsOn: dependsOn,
+	}
+
+	lc.entries = append(lc.entries, e)
+	lc.index[name] = e
+
+	return nil
+}
+
+// Start starts all the registered components in dependency order.
+// If a component fails to start, the components already started are stopped in reverse order.
+func (lc *Lifecycle) Start(ctx context.Context) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	order, err := lc.sortEntries()
+	if err != nil {
+		return err
+	}
+
+	l := logging.FromContext(ctx)
+
+	for _, e := range order {
+		l.Debug("starting component", zap.String("component", e.name))
+
+		if err := e.component.Start(ctx); err != nil {
+			err = fmt.Errorf("failed starting component %q: %w", e.name, err)
+			return multierr.Append(err, lc.stopStarted(ctx))
+		}
+
+		lc.started = append(lc.started, e)
+	}
+
+	return nil
+}
+
+// Stop stops all the started components in reverse start order.
+// All components are stopped even in case of errors, and the errors are aggregated.
+func (lc *Lifecycle) Stop(ctx context.Context) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	return lc.stopStarted(ctx)
+}
+
+// Fail reports an asynchronous failure of a running component.
+// The first failure triggers the coordinated shutdown of the application.
+// Fail does not wait for Start or Stop, so it is safe to call it from the component hooks.
+func (lc *Lifecycle) Fail(name string, err error) {
+	lc.failMu.Lock()
+	lc.failures = append(lc.failures, fmt.Errorf("component %q failure: %w", name, err))
+	lc.failMu.Unlock()
+
+	lc.failOnce.Do(func() { close(lc.failCh) })
+}
+
+// failed returns a channel that is closed when a component reports a failure.
+func (lc *Lifecycle) failed() <-chan struct{} {
+	return lc.failCh
+}
+
+// failure returns the aggregated component failures.
+func (lc *Lifecycle) failure() error {
+	lc.failMu.Lock()
+	defer lc.failMu.Unlock()
+
+	return multierr.Combine(lc.failures...)
+}
+
+func (lc *Lifecycle) stopStarted(ctx context.Context) error {
+	l := logging.FromContext(ctx)
+
+	var errs error
+
+	for i := len(lc.started) - 1; i >= 0; i-- {
+		e := lc.started[i]
+
+		l.Debug("stopping component", zap.String("component", e.name))
+
+		if err := e.component.Stop(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed stopping component %q: %w", e.name, err))
+		}
+	}
+
+	lc.started = nil
+
+	return errs
+}
+
+// sortEntries returns the components in dependency order, preserving the registration order when possible.
+func (lc *Lifecycle) sortEntries() ([]*lifecycleEntry, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(lc.entries))
+	order := make([]*lifecycleEntry, 0, len(lc.entries))
+
+	var visit func(e *lifecycleEntry) error
+
+	visit = func(e *lifecycleEntry) error {
+		switch state[e.name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependency detected on component %q", e.name)
+		}
+
+		state[e.name] = visiting
+
+		for _, dep := range e.dependsOn {
+			de, ok := lc.index[dep]
+			if !ok {
+				return fmt.Errorf("the component %q depends on the unknown component %q", e.name, dep)
+			}
+
+			if err := visit(de); err != nil {
+				return err
+			}
+		}
+
+		state[e.name] = visited
+		order = append(order, e)
+
+		return nil
+	}
+
+	for _, e := range lc.entries {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,201 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testComponentLog struct {
+	events []string
+}
+
+func (tl *testComponentLog) component(name string, startErr, stopErr error) Component {
+	return NewComponent(
+		func(ctx context.Context) error {
+			tl.events = append(tl.events, "start:"+name)
+			return startErr
+		},
+		func(ctx context.Context) error {
+			tl.events = append(tl.events, "stop:"+name)
+			return stopErr
+		},
+	)
+}
+
+func TestNewComponent(t *testing.T) {
+	t.Parallel()
+
+	c := NewComponent(nil, nil)
+	require.NoError(t, c.Start(context.Background()))
+	require.NoError(t, c.Stop(context.Background()))
+}
+
+func TestLifecycle_Register(t *testing.T) {
+	t.Parallel()
+
+	lc := NewLifecycle()
+	c := NewComponent(nil, nil)
+
+	require.Error(t, lc.Register("", c))
+	require.Error(t, lc.Register("alpha", nil))
+	require.NoError(t, lc.Register("alpha", c))
+	require.Error(t, lc.Register("alpha", c))
+}
+
+func TestLifecycle_StartStop(t *testing.T) {
+	t.Parallel()
+
+	tl := &testComponentLog{}
+	lc := NewLifecycle()
+
+	require.NoError(t, lc.Register("server", tl.component("server", nil, nil), "db", "cache"))
+	require.NoError(t, lc.Register("cache", tl.component("cache", nil, nil), "db"))
+	require.NoError(t, lc.Register("db", tl.component("db", nil, nil)))
+	require.NoError(t, lc.Register("worker", tl.component("worker", nil, nil)))
+
+	require.NoError(t, lc.Start(context.Background()))
+	require.NoError(t, lc.Stop(context.Background()))
+
+	exp := []string{
+		"start:db",
+		"start:cache",
+		"start:server",
+		"start:worker",
+		"stop:worker",
+		"stop:server",
+		"stop:cache",
+		"stop:db",
+	}
+	require.Equal(t, exp, tl.events)
+
+	// stopping twice should not stop the components again
+	require.NoError(t, lc.Stop(context.Background()))
+	require.Len(t, tl.events, len(exp))
+}
+
+func TestLifecycle_Start_error(t *testing.T) {
+	t.Parallel()
+
+	tl := &testComponentLog{}
+	lc := NewLifecycle()
+
+	require.NoError(t, lc.Register("db", tl.component("db", nil, fmt.Errorf("stop error"))))
+	require.NoError(t, lc.Register("server", tl.component("server", fmt.Errorf("start error"), nil), "db"))
+	require.NoError(t, lc.Register("worker", tl.component("worker", nil, nil), "server"))
+
+	err := lc.Start(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "start error")
+	require.Contains(t, err.Error(), "stop error")
+
+	exp := []string{
+		"start:db",
+		"start:server",
+		"stop:db",
+	}
+	require.Equal(t, exp, tl.events)
+}
+
+func TestLifecycle_Start_dependencyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		setup func(lc *Lifecycle)
+	}{
+		{
+			name: "unknown dependency",
+			setup: func(lc *Lifecycle) {
+				_ = lc.Register("alpha", NewComponent(nil, nil), "missing")
+			},
+		},
+		{
+			name: "circular dependency",
+			setup: func(lc *Lifecycle) {
+				_ = lc.Register("alpha", NewComponent(nil, nil), "beta")
+				_ = lc.Register("beta", NewComponent(nil, nil), "gamma")
+				_ = lc.Register("gamma", NewComponent(nil, nil), "alpha")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lc := NewLifecycle()
+			tt.setup(lc)
+			require.Error(t, lc.Start(context.Background()))
+		})
+	}
+}
+
+func TestLifecycle_Fail(t *testing.T) {
+	t.Parallel()
+
+	lc := NewLifecycle()
+	require.NoError(t, lc.failure())
+
+	select {
+	case <-lc.failed():
+		t.Fatal("unexpected failure signal")
+	default:
+	}
+
+	lc.Fail("alpha", fmt.Errorf("error A"))
+	lc.Fail("beta", fmt.Errorf("error B"))
+
+	<-lc.failed()
+
+	err := lc.failure()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error A")
+	require.Contains(t, err.Error(), "error B")
+}
+
+func TestLifecycle_Fail_fromStart(t *testing.T) {
+	t.Parallel()
+
+	lc := NewLifecycle()
+
+	c := NewComponent(
+		func(ctx context.Context) error {
+			lc.Fail("alpha", fmt.Errorf("error A"))
+			return nil
+		},
+		func(ctx context.Context) error {
+			lc.Fail("alpha", fmt.Errorf("error B"))
+			return nil
+		},
+	)
+	require.NoError(t, lc.Register("alpha", c))
+
+	done := make(chan error, 1)
+
+	go func() {
+		if err := lc.Start(context.Background()); err != nil {
+			done <- err
+			return
+		}
+
+		done <- lc.Stop(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("deadlock calling Fail from the component hooks")
+	}
+
+	<-lc.failed()
+
+	err := lc.failure()
+	require.Contains(t, err.Error(), "error A")
+	require.Contains(t, err.Error(), "error B")
+}
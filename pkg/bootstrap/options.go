@@ -2,7 +2,10 @@ package bootstrap
 
 import (
 	"context"
+	"os"
+	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
 	"go.uber.org/zap"
 )
 
@@ -38,3 +41,55 @@ func WithCreateMetricsClientFunc(fn CreateMetricsClientFunc) Option {
 		cfg.createMetricsClientFunc = fn
 	}
 }
+
+// WithLifecycle sets the registry of the application components to be started after the bind function
+// and gracefully stopped on shutdown.
+func WithLifecycle(lc *Lifecycle) Option {
+	return func(cfg *config) {
+		cfg.lifecycle = lc
+	}
+}
+
+// WithShutdownTimeout sets the global timeout to stop all the application components.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.shutdownTimeout = timeout
+	}
+}
+
+// WithReadiness sets the readiness flag that is set as ready when the application has started,
+// and as not ready as soon as a shutdown is requested.
+// The same flag should be passed to the status route to report the readiness to the load balancers.
+func WithReadiness(r *healthcheck.Readiness) Option {
+	return func(cfg *config) {
+		cfg.readiness = r
+	}
+}
+
+// WithShutdownDrainDelay sets the time to wait after the application is marked as not ready
+// and before the application context is canceled, to let the load balancers drain the traffic.
+func WithShutdownDrainDelay(delay time.Duration) Option {
+	return func(cfg *config) {
+		cfg.shutdownDrainDelay = delay
+	}
+}
+
+// WithSignalHandler registers a function to be called when any of the specified OS signals is received
+// while the application is running (e.g. SIGHUP, SIGUSR1, SIGUSR2).
+// The shutdown signals (SIGINT and SIGTERM) are always handled by the bootstrap.
+func WithSignalHandler(fn SignalHandlerFunc, sigs ...os.Signal) Option {
+	return func(cfg *config) {
+		for _, sig := range sigs {
+			cfg.signalHandlers = append(cfg.signalHandlers, signalHandler{sig: sig, fn: fn})
+		}
+	}
+}
+
+// WithCrashNotifyFunc sets the function used to report the application crashes to an external service.
+// It is called for panics recovered by Go, for errors returned by Bootstrap after the logger is created,
+// and before the program exits on fatal log entries.
+func WithCrashNotifyFunc(fn CrashNotifyFunc) Option {
+	return func(cfg *config) {
+		cfg.crashNotifyFunc = fn
+	}
+}
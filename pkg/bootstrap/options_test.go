@@ -2,9 +2,13 @@ package bootstrap
 
 import (
 	"context"
+	"os"
 	"reflect"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
 	"github.com/nexmoinc/gosrvlib/pkg/metrics"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -53,3 +57,60 @@ func TestWithCreateMetricsClientFunc(t *testing.T) {
 	WithCreateMetricsClientFunc(v)(cfg)
 	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(cfg.createMetricsClientFunc).Pointer())
 }
+
+func TestWithLifecycle(t *testing.T) {
+	t.Parallel()
+
+	v := NewLifecycle()
+	cfg := &config{}
+	WithLifecycle(v)(cfg)
+	require.Equal(t, v, cfg.lifecycle)
+}
+
+func TestWithShutdownTimeout(t *testing.T) {
+	t.Parallel()
+
+	v := 13 * time.Second
+	cfg := &config{}
+	WithShutdownTimeout(v)(cfg)
+	require.Equal(t, v, cfg.shutdownTimeout)
+}
+
+func TestWithReadiness(t *testing.T) {
+	t.Parallel()
+
+	v := healthcheck.NewReadiness()
+	cfg := &config{}
+	WithReadiness(v)(cfg)
+	require.Equal(t, v, cfg.readiness)
+}
+
+func TestWithShutdownDrainDelay(t *testing.T) {
+	t.Parallel()
+
+	v := 17 * time.Second
+	cfg := &config{}
+	WithShutdownDrainDelay(v)(cfg)
+	require.Equal(t, v, cfg.shutdownDrainDelay)
+}
+
+func TestWithSignalHandler(t *testing.T) {
+	t.Parallel()
+
+	v := func(ctx context.Context, sig os.Signal) {}
+	cfg := &config{}
+	WithSignalHandler(v, syscall.SIGUSR1, syscall.SIGUSR2)(cfg)
+	require.Len(t, cfg.signalHandlers, 2)
+	require.Equal(t, syscall.SIGUSR1, cfg.signalHandlers[0].sig)
+	require.Equal(t, syscall.SIGUSR2, cfg.signalHandlers[1].sig)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(cfg.signalHandlers[0].fn).Pointer())
+}
+
+func TestWithCrashNotifyFunc(t *testing.T) {
+	t.Parallel()
+
+	v := func(ctx context.Context, name string, err error) {}
+	cfg := &config{}
+	WithCrashNotifyFunc(v)(cfg)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(cfg.crashNotifyFunc).Pointer())
+}
@@ -0,0 +1,145 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+
+	appconfig "github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/profiling"
+	"go.uber.org/zap"
+)
+
+// SignalHandlerFunc is a type alias for the function called when a registered OS signal is received.
+// The context contains the application logger and is canceled on shutdown.
+type SignalHandlerFunc func(ctx context.Context, sig os.Signal)
+
+// NewConfigFunc returns a new empty application configuration object to be loaded.
+type NewConfigFunc func() appconfig.Configuration
+
+// ReloadConfigFunc applies a newly loaded and validated configuration to the running application.
+type ReloadConfigFunc func(ctx context.Context, cfg appconfig.Configuration) error
+
+type signalHandler struct {
+	sig os.Signal
+	fn  SignalHandlerFunc
+}
+
+// handleSignals dispatches the registered OS signals to their handlers until the context is canceled.
+// Multiple handlers registered for the same signal are called sequentially in registration order.
+func handleSignals(ctx context.Context, handlers []signalHandler) {
+	if len(handlers) == 0 {
+		return
+	}
+
+	sigs := make([]os.Signal, 0, len(handlers))
+	for _, h := range handlers {
+		sigs = append(sigs, h.sig)
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		l := logging.FromContext(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				l.Info("signal received", zap.String("signal", sig.String()))
+
+				for _, h := range handlers {
+					if h.sig == sig {
+						h.fn(ctx, sig)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// ReloadConfigHandler returns a signal handler that loads a new configuration with config.Load
+// and passes it to the reload function (e.g. on SIGHUP).
+// The running configuration is left untouched if the new one cannot be loaded or validated.
+func ReloadConfigHandler(cmdName, configDir, envPrefix string, newConfig NewConfigFunc, reload ReloadConfigFunc) SignalHandlerFunc {
+	return func(ctx context.Context, sig os.Signal) {
+		l := logging.FromContext(ctx)
+		cfg := newConfig()
+
+		if err := appconfig.Load(cmdName, configDir, envPrefix, cfg); err != nil {
+			l.Error("failed reloading configuration", zap.Error(err))
+			return
+		}
+
+		if err := reload(ctx, cfg); err != nil {
+			l.Error("failed applying reloaded configuration", zap.Error(err))
+			return
+		}
+
+		l.Info("configuration reloaded")
+	}
+}
+
+// ReopenLogFilesHandler returns a signal handler that reopens the log files (e.g. on SIGHUP after a log rotation).
+// Only the log outputs configured with the logging.ReopenSinkScheme are reopened.
+func ReopenLogFilesHandler() SignalHandlerFunc {
+	return func(ctx context.Context, sig os.Signal) {
+		l := logging.FromContext(ctx)
+
+		if err := logging.ReopenFiles(); err != nil {
+			l.Error("failed reopening log files", zap.Error(err))
+			return
+		}
+
+		l.Info("log files reopened")
+	}
+}
+
+// ToggleDebugLevelHandler returns a signal handler that switches the logging level
+// between debug and the level active when debug was switched on (e.g. on SIGUSR1).
+// The atomic level must be the one passed to the logger with logging.WithAtomicLevel.
+// When the level is already debug at the first toggle, it is switched to info.
+func ToggleDebugLevelHandler(level zap.AtomicLevel) SignalHandlerFunc {
+	var (
+		mu   sync.Mutex
+		base = zap.InfoLevel
+	)
+
+	return func(ctx context.Context, sig os.Signal) {
+		mu.Lock()
+
+		if cur := level.Level(); cur == zap.DebugLevel {
+			level.SetLevel(base)
+		} else {
+			// record the current level, as it may have been changed after the handler was created
+			base = cur
+			level.SetLevel(zap.DebugLevel)
+		}
+
+		mu.Unlock()
+
+		logging.FromContext(ctx).Info("logging level changed", zap.String("level", level.String()))
+	}
+}
+
+// DumpProfilesHandler returns a signal handler that writes the goroutines stack traces
+// and the heap profile into the specified directory (e.g. on SIGUSR2).
+func DumpProfilesHandler(dir string) SignalHandlerFunc {
+	return func(ctx context.Context, sig os.Signal) {
+		l := logging.FromContext(ctx)
+
+		files, err := profiling.DumpProfiles(dir)
+		if err != nil {
+			l.Error("failed dumping profiles", zap.Error(err))
+			return
+		}
+
+		l.Info("profiles dumped", zap.Strings("files", files))
+	}
+}
@@ -0,0 +1,182 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	appconfig "github.com/nexmoinc/gosrvlib/pkg/config"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testAppConfig struct {
+	appconfig.BaseConfig `mapstructure:",squash"`
+	Name                 string `mapstructure:"name"`
+}
+
+func (c *testAppConfig) SetDefaults(v appconfig.Viper) {
+	v.SetDefault("name", "default")
+}
+
+func (c *testAppConfig) Validate() error {
+	if c.Name == "invalid" {
+		return fmt.Errorf("invalid name")
+	}
+
+	return nil
+}
+
+//nolint:paralleltest
+func Test_handleSignals(t *testing.T) {
+	// cannot run in parallel because signals are received by all parallel tests
+
+	handleSignals(testutil.Context(), nil)
+
+	ctx, cancel := context.WithCancel(testutil.Context())
+	defer cancel()
+
+	received := make(chan os.Signal, 2)
+	fn := func(ctx context.Context, sig os.Signal) {
+		received <- sig
+	}
+
+	handleSignals(ctx, []signalHandler{
+		{sig: syscall.SIGUSR1, fn: fn},
+		{sig: syscall.SIGUSR1, fn: fn},
+		{sig: syscall.SIGUSR2, fn: fn},
+	})
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case sig := <-received:
+			require.Equal(t, syscall.SIGUSR1, sig)
+		case <-time.After(time.Second):
+			t.Fatal("signal handler not called")
+		}
+	}
+}
+
+func TestReloadConfigHandler(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	var loaded *testAppConfig
+
+	newConfig := func() appconfig.Configuration { return &testAppConfig{} }
+	reload := func(ctx context.Context, cfg appconfig.Configuration) error {
+		loaded = cfg.(*testAppConfig) //nolint:forcetypeassert
+		if loaded.Name == "fail" {
+			return fmt.Errorf("reload error")
+		}
+
+		return nil
+	}
+
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+	handler := ReloadConfigHandler("test", dir, "test", newConfig, reload)
+
+	// missing configuration file
+	handler(ctx, syscall.SIGHUP)
+	require.Nil(t, loaded)
+
+	writeConfig := func(name string) {
+		data := []byte(`{"name":"` + name + `"}`)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), data, 0o600))
+	}
+
+	writeConfig("invalid")
+	handler(ctx, syscall.SIGHUP)
+	require.Nil(t, loaded)
+
+	writeConfig("fail")
+	handler(ctx, syscall.SIGHUP)
+	require.Equal(t, "fail", loaded.Name)
+
+	writeConfig("alpha")
+	handler(ctx, syscall.SIGHUP)
+	require.Equal(t, "alpha", loaded.Name)
+
+	entries := logs.All()
+	require.Equal(t, "configuration reloaded", entries[len(entries)-1].Message)
+}
+
+func TestReopenLogFilesHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+	ReopenLogFilesHandler()(ctx, syscall.SIGHUP)
+
+	entries := logs.All()
+	require.Equal(t, "log files reopened", entries[len(entries)-1].Message)
+}
+
+func TestToggleDebugLevelHandler(t *testing.T) {
+	t.Parallel()
+
+	level := zap.NewAtomicLevelAt(zap.WarnLevel)
+	handler := ToggleDebugLevelHandler(level)
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.DebugLevel, level.Level())
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.WarnLevel, level.Level())
+
+	debugLevel := zap.NewAtomicLevelAt(zap.DebugLevel)
+	handler = ToggleDebugLevelHandler(debugLevel)
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.InfoLevel, debugLevel.Level())
+}
+
+func TestToggleDebugLevelHandler_levelChangedAfterCreation(t *testing.T) {
+	t.Parallel()
+
+	// the logger configuration is applied after the handler is created
+	level := zap.NewAtomicLevel()
+	handler := ToggleDebugLevelHandler(level)
+	level.SetLevel(zap.ErrorLevel)
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.DebugLevel, level.Level())
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.ErrorLevel, level.Level())
+
+	// the level changed while not in debug mode is restored too
+	level.SetLevel(zap.WarnLevel)
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.DebugLevel, level.Level())
+
+	handler(testutil.Context(), syscall.SIGUSR1)
+	require.Equal(t, zap.WarnLevel, level.Level())
+}
+
+func TestDumpProfilesHandler(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+	DumpProfilesHandler(dir)(ctx, syscall.SIGUSR2)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	DumpProfilesHandler(filepath.Join(dir, "missing"))(ctx, syscall.SIGUSR2)
+
+	entries := logs.All()
+	require.Equal(t, "failed dumping profiles", entries[len(entries)-1].Message)
+}
@@ -115,6 +115,12 @@ type LogConfig struct {
 	Address string `mapstructure:"address" validate:"omitempty,hostname_port"`
 }
 
+// LogConfig returns the logger configuration.
+// It allows to access the logging settings of any application configuration embedding BaseConfig.
+func (c *BaseConfig) LogConfig() *LogConfig {
+	return &c.Log
+}
+
 // remoteSourceConfig contains the default remote source options to be used in the application config struct.
 type remoteSourceConfig struct {
 	// Provider is the optional external configuration source: consul, etcd, firestore, envvar.
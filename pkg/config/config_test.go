@@ -1095,3 +1095,16 @@ func TestLoad(t *testing.T) {
 	err = Load("cmd", tmpConfigDir, "test", targetConfig)
 	require.NoError(t, err)
 }
+
+func TestBaseConfig_LogConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &testConfig{}
+	cfg.Log.Level = "INFO"
+
+	lc := cfg.LogConfig()
+	require.Equal(t, "INFO", lc.Level)
+
+	lc.Format = "JSON"
+	require.Equal(t, "JSON", cfg.Log.Format)
+}
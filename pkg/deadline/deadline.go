@@ -0,0 +1,56 @@
+// Package deadline provides a simple mechanism to propagate the remaining time budget of a request
+// across services with an HTTP header.
+//
+// The header value is the remaining budget in milliseconds instead of an absolute time,
+// so it is not affected by the clock skew between the hosts.
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultHeader is the default header name for the request deadline.
+const DefaultHeader = "X-Request-Deadline"
+
+// FromHTTPRequestHeader returns the remaining time budget of an HTTP Request.
+// It returns false if the header is not set or it is not a valid number of milliseconds.
+// Negative values are returned as zero (expired budget).
+func FromHTTPRequestHeader(r *http.Request, header string) (time.Duration, bool) {
+	v := r.Header.Get(header)
+	if v == "" {
+		return 0, false
+	}
+
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	if ms < 0 {
+		ms = 0
+	}
+
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// SetHTTPRequestHeaderFromContext sets the HTTP Request header with the time remaining until the context deadline.
+// The header is not set if the context has no deadline.
+// Returns the remaining time budget and true if the header has been set.
+func SetHTTPRequestHeaderFromContext(ctx context.Context, r *http.Request, header string) (time.Duration, bool) {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	budget := time.Until(dl)
+	if budget < 0 {
+		budget = 0
+	}
+
+	r.Header.Set(header, strconv.FormatInt(budget.Milliseconds(), 10))
+
+	return budget, true
+}
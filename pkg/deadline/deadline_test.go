@@ -0,0 +1,96 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromHTTPRequestHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name: "header not set",
+		},
+		{
+			name:  "invalid value",
+			value: "1s",
+		},
+		{
+			name:   "valid value",
+			value:  "1500",
+			want:   1500 * time.Millisecond,
+			wantOk: true,
+		},
+		{
+			name:   "negative value",
+			value:  "-10",
+			want:   0,
+			wantOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+			require.NoError(t, err)
+
+			if tt.value != "" {
+				r.Header.Set(DefaultHeader, tt.value)
+			}
+
+			got, ok := FromHTTPRequestHeader(r, DefaultHeader)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetHTTPRequestHeaderFromContext(t *testing.T) {
+	t.Parallel()
+
+	// no deadline
+	r1, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	_, ok := SetHTTPRequestHeaderFromContext(r1.Context(), r1, DefaultHeader)
+	require.False(t, ok)
+	require.Empty(t, r1.Header.Get(DefaultHeader))
+
+	// deadline set
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	r2, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	budget, ok := SetHTTPRequestHeaderFromContext(ctx, r2, DefaultHeader)
+	require.True(t, ok)
+	require.LessOrEqual(t, budget, time.Minute)
+	require.Greater(t, budget, 50*time.Second)
+
+	got, ok := FromHTTPRequestHeader(r2, DefaultHeader)
+	require.True(t, ok)
+	require.Equal(t, budget.Truncate(time.Millisecond), got)
+
+	// expired deadline
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	budget, ok = SetHTTPRequestHeaderFromContext(ctx, r2, DefaultHeader)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), budget)
+	require.Equal(t, "0", r2.Header.Get(DefaultHeader))
+}
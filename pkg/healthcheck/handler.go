@@ -36,10 +36,17 @@ type Handler struct {
 	checks      []HealthCheck
 	checksCount int
 	writeResult ResultWriter
+	readiness   *Readiness
 }
 
 // ServeHTTP runs the configured health checks in parallel and collects their results.
+// If a readiness flag is configured and the application is not ready, the checks are skipped.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.readiness != nil && !h.readiness.IsReady() {
+		h.writeResult(r.Context(), w, http.StatusServiceUnavailable, map[string]string{ReadinessID: ErrNotReady.Error()})
+		return
+	}
+
 	type checkResult struct {
 		id  string
 		err error
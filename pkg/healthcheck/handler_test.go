@@ -88,6 +88,30 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			wantBody:       `{"test_31":"OK","test_32":"check error"}`,
 			wantMaxElapsed: 300 * time.Millisecond,
 		},
+		{
+			name: "success with ready application",
+			checks: []HealthCheck{
+				New("test_41", &testHealthChecker{delay: 100 * time.Millisecond, err: nil}),
+			},
+			opts: []HandlerOption{
+				WithReadiness(readyReadiness()),
+			},
+			wantStatus:     http.StatusOK,
+			wantBody:       `{"test_41":"OK"}`,
+			wantMaxElapsed: 200 * time.Millisecond,
+		},
+		{
+			name: "unavailable with not ready application",
+			checks: []HealthCheck{
+				New("test_51", &testHealthChecker{delay: 100 * time.Millisecond, err: nil}),
+			},
+			opts: []HandlerOption{
+				WithReadiness(NewReadiness()),
+			},
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       `{"readiness":"not ready"}`,
+			wantMaxElapsed: 100 * time.Millisecond,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -124,3 +148,10 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func readyReadiness() *Readiness {
+	r := NewReadiness()
+	r.SetReady(true)
+
+	return r
+}
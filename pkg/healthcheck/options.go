@@ -9,3 +9,10 @@ func WithResultWriter(w ResultWriter) HandlerOption {
 		h.writeResult = w
 	}
 }
+
+// WithReadiness sets the readiness flag used to report the service as unavailable when the application is not ready.
+func WithReadiness(r *Readiness) HandlerOption {
+	return func(h *Handler) {
+		h.readiness = r
+	}
+}
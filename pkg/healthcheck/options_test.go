@@ -17,3 +17,12 @@ func TestWithResultWriter(t *testing.T) {
 	WithResultWriter(v)(h)
 	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(h.writeResult).Pointer())
 }
+
+func TestWithReadiness(t *testing.T) {
+	t.Parallel()
+
+	v := NewReadiness()
+	h := &Handler{}
+	WithReadiness(v)(h)
+	require.Equal(t, v, h.readiness)
+}
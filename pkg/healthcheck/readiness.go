@@ -0,0 +1,44 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ReadinessID is the identifier used to report the readiness status.
+const ReadinessID = "readiness"
+
+// ErrNotReady is returned when the application is not ready to receive traffic.
+var ErrNotReady = errors.New("not ready")
+
+// Readiness is a thread-safe flag reporting whether the application is ready to receive traffic.
+// It is not ready when created, to cover the application warm-up, and it is normally set as not ready
+// again at the beginning of the shutdown to let the load balancers drain the traffic.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// NewReadiness creates a new readiness flag in the "not ready" state.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady sets the readiness status.
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady returns true if the application is ready to receive traffic.
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load()
+}
+
+// HealthCheck implements the HealthChecker interface and returns ErrNotReady when the application is not ready.
+func (r *Readiness) HealthCheck(_ context.Context) error {
+	if !r.IsReady() {
+		return ErrNotReady
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	r := NewReadiness()
+	require.False(t, r.IsReady())
+	require.ErrorIs(t, r.HealthCheck(context.Background()), ErrNotReady)
+
+	r.SetReady(true)
+	require.True(t, r.IsReady())
+	require.NoError(t, r.HealthCheck(context.Background()))
+
+	r.SetReady(false)
+	require.False(t, r.IsReady())
+	require.ErrorIs(t, r.HealthCheck(context.Background()), ErrNotReady)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httputil"
 	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/deadline"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
 	"github.com/nexmoinc/gosrvlib/pkg/redact"
 	"github.com/nexmoinc/gosrvlib/pkg/traceid"
@@ -15,10 +16,11 @@ import (
 
 // Client wraps the default HTTP client functionalities and adds logging and instrumentation capabilities.
 type Client struct {
-	client            *http.Client
-	traceIDHeaderName string
-	component         string
-	redactFn          RedactFn
+	client             *http.Client
+	traceIDHeaderName  string
+	deadlineHeaderName string
+	component          string
+	redactFn           RedactFn
 }
 
 // defaultClient() returns a default client.
@@ -74,6 +76,12 @@ func (c *Client) Do(r *http.Request) (resp *http.Response, err error) {
 	reqID := traceid.FromContext(ctx, uidc.NewID128())
 	ctx = traceid.NewContext(ctx, reqID)
 	r.Header.Set(c.traceIDHeaderName, reqID)
+
+	if c.deadlineHeaderName != "" {
+		// forward the remaining time budget, including any deadline inherited from the inbound request
+		deadline.SetHTTPRequestHeaderFromContext(ctx, r, c.deadlineHeaderName)
+	}
+
 	r = r.WithContext(ctx)
 
 	l = l.With(
@@ -5,9 +5,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/deadline"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zapcore"
@@ -92,3 +94,31 @@ func TestClient_Do(t *testing.T) {
 	require.NoError(t, err, "failed reading the body content: %v", err)
 	require.Equal(t, body, responseBody)
 }
+
+func TestClient_Do_deadline(t *testing.T) {
+	t.Parallel()
+
+	budgets := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budgets <- r.Header.Get(deadline.DefaultHeader)
+	}))
+	defer server.Close()
+
+	client := New(WithTimeout(time.Minute), WithDeadlineHeaderName(deadline.DefaultHeader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	budget, err := strconv.Atoi(<-budgets)
+	require.NoError(t, err)
+	require.LessOrEqual(t, budget, 10000)
+	require.Greater(t, budget, 5000)
+}
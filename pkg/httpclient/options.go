@@ -35,6 +35,15 @@ func WithTraceIDHeaderName(name string) Option {
 	}
 }
 
+// WithDeadlineHeaderName enables the propagation of the remaining time budget of the request context
+// to the downstream services with the specified header name (e.g. deadline.DefaultHeader).
+// The budget is the time until the earliest deadline between the request context and the client timeout.
+func WithDeadlineHeaderName(name string) Option {
+	return func(c *Client) {
+		c.deadlineHeaderName = name
+	}
+}
+
 // WithComponent sets the component name to be used in logs.
 func WithComponent(name string) Option {
 	return func(c *Client) {
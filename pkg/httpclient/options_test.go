@@ -35,6 +35,15 @@ func TestWithTraceIDHeaderName(t *testing.T) {
 	require.Equal(t, v, c.traceIDHeaderName)
 }
 
+func TestWithDeadlineHeaderName(t *testing.T) {
+	t.Parallel()
+
+	c := &Client{}
+	v := "X-Test-Deadline"
+	WithDeadlineHeaderName(v)(c)
+	require.Equal(t, v, c.deadlineHeaderName)
+}
+
 func TestWithComponent(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLogConfig contains the configuration of the access log emitted when each request completes.
+type AccessLogConfig struct {
+	// Level is the log level of the access log entries. The default level is Info.
+	// The server errors (5xx status codes) are always logged at Error level.
+	Level zapcore.Level
+
+	// SampleRate is the fraction of requests to log, between 0 and 1 (e.g. 0.1 logs one request out of ten).
+	// The default value 0 logs all the requests. The server errors are always logged.
+	SampleRate float64
+
+	// ExcludedPaths contains the request URL paths that are not logged (e.g. "/ping", "/metrics").
+	ExcludedPaths []string
+}
+
+type accessLogCtxKey struct{}
+
+// accessLogEntry collects the request data set by the inner handlers.
+type accessLogEntry struct {
+	route string
+}
+
+type accessLog struct {
+	level      zapcore.Level
+	sampleRate float64
+	excluded   map[string]struct{}
+	randFn     func() float64
+}
+
+// AccessLogMiddleware returns a middleware that logs the status code, size, duration and route template
+// of each request when it completes, including the raw writes and the recovered panics.
+// The log entries contain the fields of the request-scoped logger (e.g. traceid).
+func AccessLogMiddleware(cfg AccessLogConfig) Middleware {
+	al := &accessLog{
+		level:      cfg.Level,
+		sampleRate: cfg.SampleRate,
+		excluded:   make(map[string]struct{}, len(cfg.ExcludedPaths)),
+		randFn:     rand.Float64, //nolint:gosec
+	}
+
+	for _, p := range cfg.ExcludedPaths {
+		al.excluded[p] = struct{}{}
+	}
+
+	return al.middleware
+}
+
+func (al *accessLog) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := al.excluded[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		entry := &accessLogEntry{}
+		rw := httputil.NewResponseWriterWrapper(w)
+
+		defer func() {
+			p := recover()
+
+			status := rw.Status()
+
+			switch {
+			case p != nil:
+				status = http.StatusInternalServerError
+			case status == 0:
+				status = http.StatusOK // nothing was written
+			}
+
+			al.log(r, entry, status, rw.Size(), time.Since(start))
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), accessLogCtxKey{}, entry)))
+	})
+}
+
+func (al *accessLog) log(r *http.Request, entry *accessLogEntry, status, size int, duration time.Duration) {
+	level := al.level
+
+	if status >= http.StatusInternalServerError {
+		level = zapcore.ErrorLevel
+	} else if al.sampleRate > 0 && al.sampleRate < 1 && al.randFn() >= al.sampleRate {
+		return
+	}
+
+	ce := logging.FromContext(r.Context()).Check(level, "Request completed")
+	if ce == nil {
+		return
+	}
+
+	ce.Write(
+		zap.String("route", entry.route),
+		zap.Int("response_code", status),
+		zap.Int("response_size", size),
+		zap.Duration("response_duration", duration),
+	)
+}
+
+// accessLogRouteHandler sets the route template of the access log entry, if any.
+func accessLogRouteHandler(path string, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(accessLogCtxKey{}).(*accessLogEntry); ok {
+			entry.route = path
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		cfg       AccessLogConfig
+		path      string
+		handler   http.HandlerFunc
+		wantLog   bool
+		wantLevel zapcore.Level
+		wantCode  int64
+		wantSize  int64
+		wantPanic bool
+	}{
+		{
+			name: "raw write",
+			cfg:  AccessLogConfig{},
+			path: "/items",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+			wantLog:   true,
+			wantLevel: zapcore.InfoLevel,
+			wantCode:  http.StatusOK,
+			wantSize:  5,
+		},
+		{
+			name: "http error with custom level",
+			cfg:  AccessLogConfig{Level: zapcore.DebugLevel},
+			path: "/items",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "bad", http.StatusBadRequest)
+			},
+			wantLog:   true,
+			wantLevel: zapcore.DebugLevel,
+			wantCode:  http.StatusBadRequest,
+			wantSize:  4,
+		},
+		{
+			name:      "empty response",
+			cfg:       AccessLogConfig{},
+			path:      "/items",
+			handler:   func(w http.ResponseWriter, r *http.Request) {},
+			wantLog:   true,
+			wantLevel: zapcore.InfoLevel,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name: "server error",
+			cfg:  AccessLogConfig{Level: zapcore.DebugLevel, SampleRate: 0.000001},
+			path: "/items",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantLog:   true,
+			wantLevel: zapcore.ErrorLevel,
+			wantCode:  http.StatusBadGateway,
+		},
+		{
+			name: "panic",
+			cfg:  AccessLogConfig{},
+			path: "/items",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("test")
+			},
+			wantLog:   true,
+			wantLevel: zapcore.ErrorLevel,
+			wantCode:  http.StatusInternalServerError,
+			wantPanic: true,
+		},
+		{
+			name:    "excluded path",
+			cfg:     AccessLogConfig{ExcludedPaths: []string{"/ping"}},
+			path:    "/ping",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+			mw := AccessLogMiddleware(tt.cfg)
+			handler := mw(accessLogRouteHandler("/route", tt.handler))
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, tt.path, nil)
+
+			if tt.wantPanic {
+				require.Panics(t, func() { handler.ServeHTTP(rr, req) })
+			} else {
+				handler.ServeHTTP(rr, req)
+			}
+
+			if !tt.wantLog {
+				require.Equal(t, 0, logs.Len())
+				return
+			}
+
+			entries := logs.FilterMessage("Request completed").All()
+			require.Len(t, entries, 1)
+
+			entry := entries[0]
+			fields := entry.ContextMap()
+
+			require.Equal(t, tt.wantLevel, entry.Level)
+			require.Equal(t, "/route", fields["route"])
+			require.Equal(t, tt.wantCode, fields["response_code"])
+			require.Equal(t, tt.wantSize, fields["response_size"])
+			require.Contains(t, fields, "response_duration")
+		})
+	}
+}
+
+func Test_accessLog_sampling(t *testing.T) {
+	t.Parallel()
+
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+	al := &accessLog{sampleRate: 0.5}
+	handler := al.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, v := range []float64{0.1, 0.6, 0.4, 0.9} {
+		v := v
+		al.randFn = func() float64 { return v }
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Equal(t, 2, logs.Len())
+}
+
+func Test_accessLogRouteHandler(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	handler := accessLogRouteHandler("/items/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	// no access log entry in the context
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/items/1", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.True(t, called)
+}
+
+func TestNewServer_accessLog(t *testing.T) {
+	t.Parallel()
+
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+	s, err := NewServer(ctx, NopBinder(),
+		WithServerAddr("127.0.0.1:0"),
+		WithEnableDefaultRoutes(PingRoute, StatusRoute),
+		WithAccessLog(AccessLogConfig{ExcludedPaths: []string{"/status"}}),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	for _, path := range []string{"/ping", "/status", "/missing"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+path, nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	entries := logs.FilterMessage("Request completed").All()
+	require.Len(t, entries, 2)
+
+	require.Equal(t, "/ping", entries[0].ContextMap()["route"])
+	require.Equal(t, int64(http.StatusOK), entries[0].ContextMap()["response_code"])
+	require.NotEmpty(t, entries[0].ContextMap()["traceid"])
+
+	require.Equal(t, "", entries[1].ContextMap()["route"])
+	require.Equal(t, int64(http.StatusNotFound), entries[1].ContextMap()["response_code"])
+}
@@ -0,0 +1,154 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// AdminAuthFunc is a type alias for the function authorizing the requests to the admin routes.
+// The requests are rejected with 401 Unauthorized when an error is returned.
+type AdminAuthFunc func(r *http.Request) error
+
+// RuntimeInfo contains the runtime statistics returned by the runtime admin route.
+type RuntimeInfo struct {
+	GoVersion    string       `json:"go_version"`
+	GOOS         string       `json:"goos"`
+	GOARCH       string       `json:"goarch"`
+	NumCPU       int          `json:"num_cpu"`
+	GOMAXPROCS   int          `json:"gomaxprocs"`
+	NumGoroutine int          `json:"num_goroutine"`
+	NumCgoCall   int64        `json:"num_cgo_call"`
+	MemStats     RuntimeStats `json:"memstats"`
+}
+
+// RuntimeStats contains a subset of the runtime memory allocator statistics (see runtime.MemStats).
+type RuntimeStats struct {
+	Alloc         uint64    `json:"alloc"`
+	TotalAlloc    uint64    `json:"total_alloc"`
+	Sys           uint64    `json:"sys"`
+	Mallocs       uint64    `json:"mallocs"`
+	Frees         uint64    `json:"frees"`
+	HeapAlloc     uint64    `json:"heap_alloc"`
+	HeapSys       uint64    `json:"heap_sys"`
+	HeapIdle      uint64    `json:"heap_idle"`
+	HeapInuse     uint64    `json:"heap_inuse"`
+	HeapReleased  uint64    `json:"heap_released"`
+	HeapObjects   uint64    `json:"heap_objects"`
+	StackInuse    uint64    `json:"stack_inuse"`
+	NextGC        uint64    `json:"next_gc"`
+	LastGC        time.Time `json:"last_gc"`
+	PauseTotalNs  uint64    `json:"pause_total_ns"`
+	NumGC         uint32    `json:"num_gc"`
+	NumForcedGC   uint32    `json:"num_forced_gc"`
+	GCCPUFraction float64   `json:"gc_cpu_fraction"`
+}
+
+func newRuntimeInfo() *RuntimeInfo {
+	var ms runtime.MemStats
+
+	runtime.ReadMemStats(&ms)
+
+	return &RuntimeInfo{
+		GoVersion:    runtime.Version(),
+		GOOS:         runtime.GOOS,
+		GOARCH:       runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumGoroutine: runtime.NumGoroutine(),
+		NumCgoCall:   runtime.NumCgoCall(),
+		MemStats: RuntimeStats{
+			Alloc:         ms.Alloc,
+			TotalAlloc:    ms.TotalAlloc,
+			Sys:           ms.Sys,
+			Mallocs:       ms.Mallocs,
+			Frees:         ms.Frees,
+			HeapAlloc:     ms.HeapAlloc,
+			HeapSys:       ms.HeapSys,
+			HeapIdle:      ms.HeapIdle,
+			HeapInuse:     ms.HeapInuse,
+			HeapReleased:  ms.HeapReleased,
+			HeapObjects:   ms.HeapObjects,
+			StackInuse:    ms.StackInuse,
+			NextGC:        ms.NextGC,
+			LastGC:        time.Unix(0, int64(ms.LastGC)).UTC(),
+			PauseTotalNs:  ms.PauseTotalNs,
+			NumGC:         ms.NumGC,
+			NumForcedGC:   ms.NumForcedGC,
+			GCCPUFraction: ms.GCCPUFraction,
+		},
+	}
+}
+
+// adminAuthMiddleware rejects the requests not authorized by the function.
+func adminAuthMiddleware(fn AdminAuthFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := fn(r); err != nil {
+				logging.FromContext(r.Context()).Warn("unauthorized admin request", zap.Error(err))
+				httputil.SendStatus(r.Context(), w, http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func buildInfoHandler(w http.ResponseWriter, r *http.Request) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		httputil.SendStatus(r.Context(), w, http.StatusNotImplemented)
+		return
+	}
+
+	httputil.SendJSON(r.Context(), w, http.StatusOK, bi)
+}
+
+func runtimeHandler(w http.ResponseWriter, r *http.Request) {
+	httputil.SendJSON(r.Context(), w, http.StatusOK, newRuntimeInfo())
+}
+
+func goroutinesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", httputil.MimeTextPlain)
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+
+	if err := pprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		logging.FromContext(r.Context()).Error("failed writing the goroutine dump", zap.Error(err))
+	}
+}
+
+// gcHandler forces a garbage collection and returns the updated runtime statistics.
+func gcHandler(w http.ResponseWriter, r *http.Request) {
+	logging.FromContext(r.Context()).Info("forced garbage collection")
+	debug.FreeOSMemory()
+	httputil.SendJSON(r.Context(), w, http.StatusOK, newRuntimeInfo())
+}
+
+// configHandler returns the indented JSON representation of the configuration, obscured with the redact function.
+func configHandler(data interface{}, redactFn RedactFn) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if data == nil {
+			httputil.SendStatus(r.Context(), w, http.StatusNotImplemented)
+			return
+		}
+
+		b, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			logging.FromContext(r.Context()).Error("failed encoding the configuration", zap.Error(err))
+			httputil.SendStatus(r.Context(), w, http.StatusInternalServerError)
+
+			return
+		}
+
+		httputil.SendJSON(r.Context(), w, http.StatusOK, json.RawMessage(redactFn(string(b))))
+	}
+}
@@ -0,0 +1,182 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/redact"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testAdminBinder struct{}
+
+func (b *testAdminBinder) BindHTTP(_ context.Context) []route.Route {
+	return nil
+}
+
+func TestAdminRoutes(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	authFn := func(r *http.Request) error {
+		if r.Header.Get("X-Admin-Token") != "secret" {
+			return errors.New("invalid admin token")
+		}
+
+		return nil
+	}
+
+	s, err := NewServer(ctx, &testAdminBinder{},
+		WithServerAddr("127.0.0.1:0"),
+		WithEnableAdminRoutes(),
+		WithEnableDefaultRoutes(PingRoute),
+		WithAdminAuthFunc(authFn),
+		WithLogLevel(level),
+		WithConfigData(map[string]string{"name": "test", "api_key": "0123456789"}),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	baseURL := "http://" + s.Addr().String()
+
+	do := func(method, path, token, body string) (int, string) {
+		req, err := http.NewRequestWithContext(ctx, method, baseURL+path, strings.NewReader(body))
+		require.NoError(t, err)
+
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	// default routes are not protected
+	code, _ := do(http.MethodGet, pingHandlerPath, "", "")
+	require.Equal(t, http.StatusOK, code)
+
+	for _, path := range []string{buildInfoHandlerPath, runtimeHandlerPath, goroutinesHandlerPath, logLevelHandlerPath, configHandlerPath} {
+		code, _ = do(http.MethodGet, path, "", "")
+		require.Equal(t, http.StatusUnauthorized, code, path)
+
+		code, _ = do(http.MethodGet, path, "wrong", "")
+		require.Equal(t, http.StatusUnauthorized, code, path)
+	}
+
+	code, body := do(http.MethodGet, runtimeHandlerPath, "secret", "")
+	require.Equal(t, http.StatusOK, code)
+
+	var ri RuntimeInfo
+
+	require.NoError(t, json.Unmarshal([]byte(body), &ri))
+	require.NotEmpty(t, ri.GoVersion)
+	require.Positive(t, ri.NumGoroutine)
+
+	code, body = do(http.MethodGet, goroutinesHandlerPath, "secret", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, "goroutine ")
+
+	code, body = do(http.MethodPost, gcHandlerPath, "secret", "")
+	require.Equal(t, http.StatusOK, code)
+	require.NoError(t, json.Unmarshal([]byte(body), &ri))
+	require.Positive(t, ri.MemStats.NumForcedGC)
+
+	code, _ = do(http.MethodGet, buildInfoHandlerPath, "secret", "")
+	require.Contains(t, []int{http.StatusOK, http.StatusNotImplemented}, code)
+
+	code, body = do(http.MethodGet, logLevelHandlerPath, "secret", "")
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"level":"info"}`, body)
+
+	code, _ = do(http.MethodPut, logLevelHandlerPath, "secret", `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, zapcore.DebugLevel, level.Level())
+
+	code, body = do(http.MethodGet, configHandlerPath, "secret", "")
+	require.Equal(t, http.StatusOK, code)
+	require.JSONEq(t, `{"name":"test","api_key":"@~REDACTED~@"}`, body)
+}
+
+func Test_buildInfoHandler(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+	buildInfoHandler(rr, req)
+
+	// the build information is embedded in the test binaries
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `"Path"`)
+}
+
+func Test_configHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     interface{}
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "not set",
+			wantCode: http.StatusNotImplemented,
+		},
+		{
+			name:     "encoding error",
+			data:     math.Inf(1),
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "redacted",
+			data: struct {
+				User     string `json:"user"`
+				Password string `json:"password"`
+			}{
+				User:     "alpha",
+				Password: "beta",
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"user":"alpha","password":"@~REDACTED~@"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+			configHandler(tt.data, redact.HTTPData)(rr, req)
+
+			require.Equal(t, tt.wantCode, rr.Code)
+
+			if tt.wantBody != "" {
+				require.JSONEq(t, tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
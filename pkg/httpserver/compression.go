@@ -0,0 +1,419 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// EncodingGzip is the gzip content encoding.
+	EncodingGzip = "gzip"
+
+	// EncodingBrotli is the brotli content encoding.
+	EncodingBrotli = "br"
+
+	// DefaultCompressionMinSize is the default minimum response size in bytes to apply compression.
+	DefaultCompressionMinSize = 1024
+)
+
+// DefaultCompressionContentTypes is the default list of compressible content types.
+var DefaultCompressionContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-ndjson",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+// CompressionConfig contains the response compression settings.
+type CompressionConfig struct {
+	// MinSize is the minimum response size in bytes to apply compression.
+	// A zero value sets DefaultCompressionMinSize.
+	MinSize int
+
+	// ContentTypes is the allow-list of compressible content types.
+	// Each entry is matched as a prefix of the response Content-Type (e.g. "text/" matches "text/html; charset=utf-8").
+	// An empty list sets DefaultCompressionContentTypes.
+	ContentTypes []string
+
+	// GzipLevel is the gzip compression level (see compress/gzip).
+	// A nil value sets gzip.DefaultCompression.
+	GzipLevel *int
+
+	// BrotliLevel is the brotli compression level (0-11).
+	// A nil value sets brotli.DefaultCompression.
+	BrotliLevel *int
+
+	// DisableBrotli excludes the brotli encoding even if accepted by the client.
+	DisableBrotli bool
+}
+
+// compressionLevels contains the compression levels with the defaults applied.
+type compressionLevels struct {
+	gzip   int
+	brotli int
+}
+
+// CompressionMiddleware returns a middleware that compresses the response body with gzip or brotli,
+// according to the Accept-Encoding request header.
+// The response is compressed only if the body is larger than the minimum size,
+// the content type is in the allow-list and no other content encoding is already set.
+// An error is returned if the compression levels are invalid.
+func CompressionMiddleware(cfg CompressionConfig) (Middleware, error) {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultCompressionMinSize
+	}
+
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = DefaultCompressionContentTypes
+	}
+
+	levels := compressionLevels{
+		gzip:   gzip.DefaultCompression,
+		brotli: brotli.DefaultCompression,
+	}
+
+	if cfg.GzipLevel != nil {
+		levels.gzip = *cfg.GzipLevel
+	}
+
+	if cfg.BrotliLevel != nil {
+		levels.brotli = *cfg.BrotliLevel
+	}
+
+	if levels.gzip < gzip.HuffmanOnly || levels.gzip > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid gzip compression level: %d", levels.gzip)
+	}
+
+	if levels.brotli < brotli.BestSpeed || levels.brotli > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid brotli compression level: %d", levels.brotli)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), !cfg.DisableBrotli)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				cfg:            &cfg,
+				levels:         levels,
+				encoding:       encoding,
+				status:         http.StatusOK,
+			}
+
+			defer cw.close()
+
+			next.ServeHTTP(cw.withInterfaces(), r)
+		})
+	}, nil
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality value in the Accept-Encoding header.
+// Brotli is preferred over gzip when the quality values are the same.
+func negotiateEncoding(acceptEncoding string, brotliEnabled bool) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qvalues := make(map[string]float64, 3)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			pq, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+
+			q = pq
+		}
+
+		qvalues[name] = q
+	}
+
+	best, bestQ := "", 0.0
+
+	candidates := []string{EncodingGzip}
+	if brotliEnabled {
+		candidates = []string{EncodingBrotli, EncodingGzip}
+	}
+
+	for _, enc := range candidates {
+		q, ok := qvalues[enc]
+		if !ok {
+			q, ok = qvalues["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
+
+// compressResponseWriter buffers the response body until the compression decision can be made.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	cfg         *CompressionConfig
+	levels      compressionLevels
+	encoding    string
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         bytes.Buffer
+	encoder     io.WriteCloser
+}
+
+// WriteHeader records the status code. The header is sent when the compression decision is made.
+// The informational 1xx headers (e.g. 103 Early Hints) are sent immediately.
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	if isInformationalStatus(code) {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.wroteHeader = true
+	cw.status = code
+
+	if !bodyAllowedForStatus(code) || cw.Header().Get("Content-Encoding") != "" {
+		cw.decided = true
+		cw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+// Write buffers or compresses the response body.
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(b) //nolint:wrapcheck
+	}
+
+	if cw.decided {
+		return cw.ResponseWriter.Write(b) //nolint:wrapcheck
+	}
+
+	n, _ := cw.buf.Write(b)
+
+	if cw.buf.Len() >= cw.cfg.MinSize {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (cw *compressResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(writerOnly{cw}, r) //nolint:wrapcheck
+}
+
+// withInterfaces returns the writer implementing the http.Flusher and http.Hijacker interfaces
+// only if they are implemented by the wrapped writer, so the type assertions behave as on the original writer.
+func (cw *compressResponseWriter) withInterfaces() http.ResponseWriter {
+	_, isFlusher := cw.ResponseWriter.(http.Flusher)
+	_, isHijacker := cw.ResponseWriter.(http.Hijacker)
+
+	f := compressFlusher{cw}
+	h := compressHijacker{cw}
+
+	switch {
+	case isFlusher && isHijacker:
+		return struct {
+			*compressResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{cw, f, h}
+	case isFlusher:
+		return struct {
+			*compressResponseWriter
+			http.Flusher
+		}{cw, f}
+	case isHijacker:
+		return struct {
+			*compressResponseWriter
+			http.Hijacker
+		}{cw, h}
+	}
+
+	return cw
+}
+
+// compressFlusher implements http.Flusher for the wrapped writers implementing it.
+type compressFlusher struct {
+	*compressResponseWriter
+}
+
+// Flush implements the http.Flusher interface.
+// Flushing forces the compression decision with the data buffered so far.
+func (f compressFlusher) Flush() {
+	cw := f.compressResponseWriter
+
+	if !cw.decided {
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+
+		_ = cw.decide()
+	}
+
+	if ef, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = ef.Flush()
+	}
+
+	cw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// compressHijacker implements http.Hijacker for the wrapped writers implementing it.
+type compressHijacker struct {
+	*compressResponseWriter
+}
+
+// Hijack implements the http.Hijacker interface.
+//
+//nolint:wrapcheck
+func (h compressHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the header and the buffered data, compressed or not.
+func (cw *compressResponseWriter) decide() error {
+	cw.decided = true
+
+	h := cw.Header()
+
+	if cw.buf.Len() >= cw.cfg.MinSize && cw.isCompressible() {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		cw.encoder = cw.newEncoder()
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+
+	cw.buf.Reset()
+
+	return err //nolint:wrapcheck
+}
+
+func (cw *compressResponseWriter) isCompressible() bool {
+	h := cw.Header()
+
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(cw.buf.Bytes())
+		h.Set("Content-Type", ct)
+	}
+
+	ct = strings.ToLower(ct)
+
+	for _, allowed := range cw.cfg.ContentTypes {
+		if strings.HasPrefix(ct, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressResponseWriter) newEncoder() io.WriteCloser {
+	if cw.encoding == EncodingBrotli {
+		return brotli.NewWriterLevel(cw.ResponseWriter, cw.levels.brotli)
+	}
+
+	// the level is validated by CompressionMiddleware
+	gz, _ := gzip.NewWriterLevel(cw.ResponseWriter, cw.levels.gzip)
+
+	return gz
+}
+
+// close completes the response.
+func (cw *compressResponseWriter) close() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			// nothing has been written by the handler
+			return
+		}
+
+		_ = cw.decide()
+	}
+
+	if cw.encoder != nil {
+		_ = cw.encoder.Close()
+	}
+}
+
+// writerOnly hides the io.ReaderFrom implementation to avoid the io.Copy recursion.
+type writerOnly struct {
+	io.Writer
+}
+
+// isInformationalStatus reports whether the status code is an informational 1xx status
+// that precedes the final response status (i.e. any 1xx status except 101 Switching Protocols).
+func isInformationalStatus(status int) bool {
+	return status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols
+}
+
+// bodyAllowedForStatus reports whether a given response status code permits a body (see RFC 7230, section 3.3).
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
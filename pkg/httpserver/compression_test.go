@@ -0,0 +1,408 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		brotliEnabled  bool
+		want           string
+	}{
+		{name: "empty", acceptEncoding: "", brotliEnabled: true, want: ""},
+		{name: "identity", acceptEncoding: "identity", brotliEnabled: true, want: ""},
+		{name: "gzip", acceptEncoding: "gzip, deflate", brotliEnabled: true, want: EncodingGzip},
+		{name: "brotli preferred", acceptEncoding: "gzip, deflate, br", brotliEnabled: true, want: EncodingBrotli},
+		{name: "brotli disabled", acceptEncoding: "gzip, br", brotliEnabled: false, want: EncodingGzip},
+		{name: "quality", acceptEncoding: "br;q=0.5, gzip;q=0.8", brotliEnabled: true, want: EncodingGzip},
+		{name: "refused", acceptEncoding: "gzip;q=0, br;q=0", brotliEnabled: true, want: ""},
+		{name: "wildcard", acceptEncoding: "*", brotliEnabled: true, want: EncodingBrotli},
+		{name: "invalid quality", acceptEncoding: "br;q=x, gzip", brotliEnabled: true, want: EncodingGzip},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, negotiateEncoding(tt.acceptEncoding, tt.brotliEnabled))
+		})
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	t.Parallel()
+
+	largeBody := strings.Repeat("compressible text ", 100)
+
+	tests := []struct {
+		name           string
+		cfg            CompressionConfig
+		method         string
+		acceptEncoding string
+		contentType    string
+		status         int
+		body           string
+		wantEncoding   string
+	}{
+		{
+			name:           "gzip",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "text/plain; charset=utf-8",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   EncodingGzip,
+		},
+		{
+			name:           "brotli",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip, br",
+			contentType:    "application/json",
+			status:         http.StatusCreated,
+			body:           largeBody,
+			wantEncoding:   EncodingBrotli,
+		},
+		{
+			name:           "detected content type",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   EncodingGzip,
+		},
+		{
+			name:         "no accept encoding",
+			method:       http.MethodGet,
+			contentType:  "text/plain",
+			status:       http.StatusOK,
+			body:         largeBody,
+			wantEncoding: "",
+		},
+		{
+			name:           "small body",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			body:           "small",
+			wantEncoding:   "",
+		},
+		{
+			name:           "gzip without compression",
+			cfg:            CompressionConfig{GzipLevel: intPtr(gzip.NoCompression)},
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   EncodingGzip,
+		},
+		{
+			name:           "brotli best speed",
+			cfg:            CompressionConfig{BrotliLevel: intPtr(brotli.BestSpeed)},
+			method:         http.MethodGet,
+			acceptEncoding: "br",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   EncodingBrotli,
+		},
+		{
+			name:           "custom min size",
+			cfg:            CompressionConfig{MinSize: 3},
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			body:           "small",
+			wantEncoding:   EncodingGzip,
+		},
+		{
+			name:           "content type not allowed",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "image/png",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   "",
+		},
+		{
+			name:           "custom content type",
+			cfg:            CompressionConfig{ContentTypes: []string{"application/octet-stream"}},
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			contentType:    "application/octet-stream",
+			status:         http.StatusOK,
+			body:           largeBody,
+			wantEncoding:   EncodingGzip,
+		},
+		{
+			name:           "no content",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			status:         http.StatusNoContent,
+			wantEncoding:   "",
+		},
+		{
+			name:           "head",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			wantEncoding:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+
+				w.WriteHeader(tt.status)
+
+				// write in chunks to exercise the buffering
+				for i := 0; i < len(tt.body); i += 100 {
+					end := i + 100
+					if end > len(tt.body) {
+						end = len(tt.body)
+					}
+
+					_, _ = w.Write([]byte(tt.body[i:end]))
+				}
+			})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			rr := httptest.NewRecorder()
+			newTestCompressionMiddleware(t, tt.cfg)(next).ServeHTTP(rr, req)
+
+			resp := rr.Result()
+			defer func() { _ = resp.Body.Close() }()
+
+			require.Equal(t, tt.status, resp.StatusCode)
+			require.Equal(t, tt.wantEncoding, resp.Header.Get("Content-Encoding"))
+			require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+			require.Equal(t, tt.body, decodeBody(t, tt.wantEncoding, resp.Body))
+		})
+	}
+}
+
+func TestCompressionMiddleware_contentEncodingSet(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte{'a'}, 2048)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "custom")
+		_, _ = w.Write(body)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rr := httptest.NewRecorder()
+	newTestCompressionMiddleware(t, CompressionConfig{})(next).ServeHTTP(rr, req)
+
+	require.Equal(t, "custom", rr.Header().Get("Content-Encoding"))
+	require.Equal(t, body, rr.Body.Bytes())
+}
+
+func TestCompressionMiddleware_responseWriterWrapper(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("compressible text ", 10)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := httputil.NewResponseWriterWrapper(w)
+
+		ww.Header().Set("Content-Type", "text/plain")
+		ww.WriteHeader(http.StatusAccepted)
+
+		_, err := io.Copy(ww, strings.NewReader(body))
+		require.NoError(t, err)
+
+		// flushing forces the compression of the data buffered so far
+		ww.(http.Flusher).Flush()
+
+		require.Equal(t, http.StatusAccepted, ww.Status())
+		require.Equal(t, len(body), ww.Size())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rr := httptest.NewRecorder()
+	newTestCompressionMiddleware(t, CompressionConfig{MinSize: 10})(next).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+	require.True(t, rr.Flushed)
+	require.Equal(t, EncodingGzip, rr.Header().Get("Content-Encoding"))
+	require.Equal(t, body, decodeBody(t, EncodingGzip, rr.Body))
+}
+
+func decodeBody(t *testing.T, encoding string, r io.Reader) string {
+	t.Helper()
+
+	var err error
+
+	switch encoding {
+	case EncodingGzip:
+		r, err = gzip.NewReader(r)
+		require.NoError(t, err)
+	case EncodingBrotli:
+		r = brotli.NewReader(r)
+	}
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func newTestCompressionMiddleware(t *testing.T, cfg CompressionConfig) Middleware {
+	t.Helper()
+
+	mw, err := CompressionMiddleware(cfg)
+	require.NoError(t, err)
+
+	return mw
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCompressionMiddleware_invalidLevels(t *testing.T) {
+	t.Parallel()
+
+	_, err := CompressionMiddleware(CompressionConfig{GzipLevel: intPtr(10)})
+	require.Error(t, err)
+
+	_, err = CompressionMiddleware(CompressionConfig{BrotliLevel: intPtr(12)})
+	require.Error(t, err)
+
+	_, err = CompressionMiddleware(CompressionConfig{GzipLevel: intPtr(gzip.HuffmanOnly), BrotliLevel: intPtr(0)})
+	require.NoError(t, err)
+}
+
+func TestCompressionMiddleware_informationalStatus(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("compressible text ", 100)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(body))
+	})
+
+	srv := httptest.NewServer(newTestCompressionMiddleware(t, CompressionConfig{})(next))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(testutil.Context(), http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, EncodingGzip, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, body, decodeBody(t, EncodingGzip, resp.Body))
+}
+
+type testCompressHijacker struct {
+	called bool
+}
+
+func (h *testCompressHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.called = true
+	return nil, nil, nil
+}
+
+func TestCompressionMiddleware_optionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	for _, flusher := range []bool{false, true} {
+		for _, hijacker := range []bool{false, true} {
+			rr := httptest.NewRecorder()
+			hj := &testCompressHijacker{}
+
+			var w http.ResponseWriter
+
+			switch {
+			case flusher && hijacker:
+				w = struct {
+					http.ResponseWriter
+					http.Flusher
+					http.Hijacker
+				}{rr, rr, hj}
+			case flusher:
+				w = rr
+			case hijacker:
+				w = struct {
+					http.ResponseWriter
+					http.Hijacker
+				}{rr, hj}
+			default:
+				w = struct{ http.ResponseWriter }{rr}
+			}
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				f, ok := w.(http.Flusher)
+				require.Equal(t, flusher, ok, "http.Flusher")
+
+				if ok {
+					f.Flush()
+				}
+
+				h, ok := w.(http.Hijacker)
+				require.Equal(t, hijacker, ok, "http.Hijacker")
+
+				if ok {
+					_, _, err := h.Hijack()
+					require.NoError(t, err)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			newTestCompressionMiddleware(t, CompressionConfig{})(next).ServeHTTP(w, req)
+
+			require.Equal(t, flusher, rr.Flushed)
+			require.Equal(t, hijacker, hj.called)
+		}
+	}
+}
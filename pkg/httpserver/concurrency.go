@@ -0,0 +1,296 @@
+package httpserver
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+)
+
+const (
+	// DefaultConcurrencyLimitName is the default concurrency limiter name used as metrics operation.
+	DefaultConcurrencyLimitName = "default"
+
+	// DefaultAdaptiveBackoffRatio is the default multiplicative decrease factor of the adaptive concurrency limit.
+	DefaultAdaptiveBackoffRatio = 0.9
+
+	concurrencyMetricTask = "concurrency"
+
+	concurrencyGaugeLimit    = "limit"
+	concurrencyGaugeInFlight = "in_flight"
+)
+
+// ConcurrencyLimitConfig contains the concurrency limiting settings.
+type ConcurrencyLimitConfig struct {
+	// Name identifies the limiter in the metrics.
+	// The default value is DefaultConcurrencyLimitName.
+	Name string
+
+	// Limit is the maximum number of in-flight requests.
+	// In adaptive mode this is the initial limit.
+	Limit int
+
+	// QueueTimeout is the maximum time a request waits for a free slot before being rejected.
+	// A zero value rejects the requests immediately when the limit is reached.
+	QueueTimeout time.Duration
+
+	// MaxQueue is the maximum number of waiting requests. A zero value does not limit the queue size.
+	MaxQueue int
+
+	// Adaptive enables the automatic adjustment of the limit when set.
+	Adaptive *AdaptiveConcurrencyConfig
+
+	// Metrics is the optional client used to count the rejected requests.
+	// If the client implements metrics.GaugeSetter, the current limit and number of in-flight requests
+	// are also reported as the "limit" and "in_flight" gauges.
+	Metrics metrics.Client
+}
+
+// AdaptiveConcurrencyConfig contains the settings of the adaptive concurrency limit.
+// The limit is adjusted with an Additive Increase Multiplicative Decrease (AIMD) algorithm:
+// it is increased by one when a request completes successfully while the limiter is at least half utilized,
+// and multiplied by BackoffRatio when a request is slower than LatencyThreshold or fails with a 5xx status.
+// The limit is decreased at most once per LatencyThreshold window,
+// so a burst of slow requests caused by the same overload only backs off once.
+type AdaptiveConcurrencyConfig struct {
+	// MinLimit is the lowest limit. The default value is 1.
+	MinLimit int
+
+	// MaxLimit is the highest limit. The default value is the initial limit.
+	MaxLimit int
+
+	// LatencyThreshold is the request latency above which the limit is decreased.
+	LatencyThreshold time.Duration
+
+	// BackoffRatio is the multiplicative decrease factor, between 0 and 1.
+	// The default value is DefaultAdaptiveBackoffRatio.
+	BackoffRatio float64
+}
+
+// ConcurrencyLimiter caps the number of in-flight requests.
+type ConcurrencyLimiter struct {
+	mu          sync.Mutex
+	cfg         ConcurrencyLimitConfig
+	gauges      metrics.GaugeSetter
+	limit       int
+	inFlight    int
+	lastBackoff time.Time
+	waiters     *list.List
+}
+
+// NewConcurrencyLimiter creates a new concurrency limiter.
+// Use the Middleware method to limit a single route via route.Route.Middlewares,
+// or the WithConcurrencyLimiter option to limit all the requests.
+func NewConcurrencyLimiter(cfg ConcurrencyLimitConfig) (*ConcurrencyLimiter, error) {
+	if cfg.Limit <= 0 {
+		return nil, fmt.Errorf("the concurrency limit must be positive: %d", cfg.Limit)
+	}
+
+	if cfg.QueueTimeout < 0 || cfg.MaxQueue < 0 {
+		return nil, fmt.Errorf("the concurrency queue timeout and size must not be negative")
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = DefaultConcurrencyLimitName
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = &metrics.Default{}
+	}
+
+	if cfg.Adaptive != nil {
+		ac := *cfg.Adaptive
+
+		if err := ac.setDefaults(cfg.Limit); err != nil {
+			return nil, err
+		}
+
+		cfg.Adaptive = &ac
+	}
+
+	cl := &ConcurrencyLimiter{
+		cfg:     cfg,
+		limit:   cfg.Limit,
+		waiters: list.New(),
+	}
+
+	cl.gauges, _ = cfg.Metrics.(metrics.GaugeSetter)
+	cl.reportGauges()
+
+	return cl, nil
+}
+
+func (ac *AdaptiveConcurrencyConfig) setDefaults(limit int) error {
+	if ac.MinLimit == 0 {
+		ac.MinLimit = 1
+	}
+
+	if ac.MaxLimit == 0 {
+		ac.MaxLimit = limit
+	}
+
+	if ac.BackoffRatio == 0 {
+		ac.BackoffRatio = DefaultAdaptiveBackoffRatio
+	}
+
+	if ac.MinLimit < 1 || ac.MinLimit > limit || ac.MaxLimit < limit {
+		return fmt.Errorf("the adaptive concurrency limits must satisfy 1 <= MinLimit (%d) <= Limit (%d) <= MaxLimit (%d)", ac.MinLimit, limit, ac.MaxLimit)
+	}
+
+	if ac.LatencyThreshold <= 0 {
+		return fmt.Errorf("the adaptive concurrency latency threshold must be positive: %s", ac.LatencyThreshold)
+	}
+
+	if ac.BackoffRatio <= 0 || ac.BackoffRatio >= 1 {
+		return fmt.Errorf("the adaptive concurrency backoff ratio must be between 0 and 1: %v", ac.BackoffRatio)
+	}
+
+	return nil
+}
+
+// Limit returns the current maximum number of in-flight requests.
+func (cl *ConcurrencyLimiter) Limit() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	return cl.limit
+}
+
+// InFlight returns the current number of in-flight requests.
+func (cl *ConcurrencyLimiter) InFlight() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	return cl.inFlight
+}
+
+// Middleware returns a middleware that limits the number of in-flight requests.
+// The requests exceeding the limit are queued up to the QueueTimeout, then rejected with 503 Service Unavailable.
+func (cl *ConcurrencyLimiter) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			if code, ok := cl.acquire(ctx); !ok {
+				cl.cfg.Metrics.IncErrorCounter(concurrencyMetricTask, cl.cfg.Name, code)
+				httputil.SendStatus(ctx, w, http.StatusServiceUnavailable)
+
+				return
+			}
+
+			if cl.cfg.Adaptive == nil {
+				defer cl.release(false)
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			ww := httputil.NewResponseWriterWrapper(w)
+			start := time.Now()
+
+			defer func() {
+				cl.release(time.Since(start) > cl.cfg.Adaptive.LatencyThreshold || ww.Status() >= http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// acquire reserves a slot, waiting in the queue if required.
+// It returns the metrics code of the rejection reason when the slot is not acquired.
+func (cl *ConcurrencyLimiter) acquire(ctx context.Context) (string, bool) {
+	cl.mu.Lock()
+
+	if cl.inFlight < cl.limit && cl.waiters.Len() == 0 {
+		cl.inFlight++
+		cl.reportGauges()
+		cl.mu.Unlock()
+
+		return "", true
+	}
+
+	if cl.cfg.QueueTimeout == 0 || (cl.cfg.MaxQueue > 0 && cl.waiters.Len() >= cl.cfg.MaxQueue) {
+		cl.mu.Unlock()
+		return "rejected", false
+	}
+
+	ready := make(chan struct{})
+	elem := cl.waiters.PushBack(ready)
+
+	cl.mu.Unlock()
+
+	timer := time.NewTimer(cl.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		return "", true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	select {
+	case <-ready:
+		// the slot has been granted while timing out
+		return "", true
+	default:
+	}
+
+	cl.waiters.Remove(elem)
+
+	return "timeout", false
+}
+
+// release frees a slot, adjusts the adaptive limit and grants the free slots to the queued requests.
+func (cl *ConcurrencyLimiter) release(overloaded bool) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if ac := cl.cfg.Adaptive; ac != nil {
+		switch {
+		case overloaded:
+			// back off only once per latency window
+			if now := time.Now(); now.Sub(cl.lastBackoff) >= ac.LatencyThreshold {
+				cl.lastBackoff = now
+
+				cl.limit = int(float64(cl.limit) * ac.BackoffRatio)
+				if cl.limit < ac.MinLimit {
+					cl.limit = ac.MinLimit
+				}
+			}
+		case 2*cl.inFlight >= cl.limit && cl.limit < ac.MaxLimit:
+			cl.limit++
+		}
+	}
+
+	cl.inFlight--
+
+	for cl.inFlight < cl.limit && cl.waiters.Len() > 0 {
+		ready, _ := cl.waiters.Remove(cl.waiters.Front()).(chan struct{})
+		cl.inFlight++
+
+		close(ready)
+	}
+
+	cl.reportGauges()
+}
+
+// reportGauges reports the current limit and number of in-flight requests, if supported by the metrics client.
+// It is called with the mutex locked, so the reported values are in order.
+func (cl *ConcurrencyLimiter) reportGauges() {
+	if cl.gauges == nil {
+		return
+	}
+
+	cl.gauges.SetGauge(concurrencyMetricTask, cl.cfg.Name, concurrencyGaugeLimit, float64(cl.limit))
+	cl.gauges.SetGauge(concurrencyMetricTask, cl.cfg.Name, concurrencyGaugeInFlight, float64(cl.inFlight))
+}
@@ -0,0 +1,312 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+type testGaugeMetrics struct {
+	metrics.Default
+	mu     sync.Mutex
+	gauges map[string]float64
+}
+
+func (m *testGaugeMetrics) SetGauge(task, operation, name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gauges[task+":"+operation+":"+name] = value
+}
+
+func (m *testGaugeMetrics) gauge(key string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.gauges[key]
+}
+
+func TestNewConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     ConcurrencyLimitConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg:  ConcurrencyLimitConfig{Limit: 10},
+		},
+		{
+			name: "valid adaptive",
+			cfg:  ConcurrencyLimitConfig{Limit: 10, Adaptive: &AdaptiveConcurrencyConfig{LatencyThreshold: time.Second}},
+		},
+		{
+			name:    "invalid limit",
+			cfg:     ConcurrencyLimitConfig{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid queue timeout",
+			cfg:     ConcurrencyLimitConfig{Limit: 10, QueueTimeout: -1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid adaptive limits",
+			cfg:     ConcurrencyLimitConfig{Limit: 10, Adaptive: &AdaptiveConcurrencyConfig{MaxLimit: 5, LatencyThreshold: time.Second}},
+			wantErr: true,
+		},
+		{
+			name:    "missing adaptive latency threshold",
+			cfg:     ConcurrencyLimitConfig{Limit: 10, Adaptive: &AdaptiveConcurrencyConfig{}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid adaptive backoff ratio",
+			cfg:     ConcurrencyLimitConfig{Limit: 10, Adaptive: &AdaptiveConcurrencyConfig{LatencyThreshold: time.Second, BackoffRatio: 1.5}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cl, err := NewConcurrencyLimiter(tt.cfg)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, cl)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.cfg.Limit, cl.Limit())
+			require.Equal(t, 0, cl.InFlight())
+		})
+	}
+}
+
+// testBlockingHandler returns a handler blocked until the release channel is closed.
+func testBlockingHandler(release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+}
+
+// testServeAsync serves a request in the background and returns the channel receiving the status code.
+func testServeAsync(h http.Handler) <-chan int {
+	codes := make(chan int, 1)
+
+	go func() {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes <- rr.Code
+	}()
+
+	return codes
+}
+
+func testWaitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	require.Eventually(t, cond, 5*time.Second, time.Millisecond)
+}
+
+func TestConcurrencyLimiter_reject(t *testing.T) {
+	t.Parallel()
+
+	m := &testErrorMetrics{}
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{Name: "api", Limit: 1, Metrics: m})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	h := cl.Middleware()(testBlockingHandler(release))
+
+	first := testServeAsync(h)
+	testWaitFor(t, func() bool { return cl.InFlight() == 1 })
+
+	require.Equal(t, http.StatusServiceUnavailable, <-testServeAsync(h))
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-first)
+	require.Equal(t, 0, cl.InFlight())
+	require.Equal(t, []string{"concurrency:api:rejected"}, m.codes)
+}
+
+func TestConcurrencyLimiter_queue(t *testing.T) {
+	t.Parallel()
+
+	m := &testErrorMetrics{}
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{Limit: 1, QueueTimeout: 5 * time.Second, MaxQueue: 1, Metrics: m})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	h := cl.Middleware()(testBlockingHandler(release))
+
+	first := testServeAsync(h)
+	testWaitFor(t, func() bool { return cl.InFlight() == 1 })
+
+	second := testServeAsync(h)
+	testWaitFor(t, func() bool {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+
+		return cl.waiters.Len() == 1
+	})
+
+	// the queue is full
+	require.Equal(t, http.StatusServiceUnavailable, <-testServeAsync(h))
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-first)
+	require.Equal(t, http.StatusOK, <-second)
+	require.Equal(t, 0, cl.InFlight())
+	require.Equal(t, []string{"concurrency:default:rejected"}, m.codes)
+}
+
+func TestConcurrencyLimiter_queueTimeout(t *testing.T) {
+	t.Parallel()
+
+	m := &testErrorMetrics{}
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{Limit: 1, QueueTimeout: 10 * time.Millisecond, Metrics: m})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	h := cl.Middleware()(testBlockingHandler(release))
+
+	first := testServeAsync(h)
+	testWaitFor(t, func() bool { return cl.InFlight() == 1 })
+
+	require.Equal(t, http.StatusServiceUnavailable, <-testServeAsync(h))
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-first)
+	require.Equal(t, []string{"concurrency:default:timeout"}, m.codes)
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	require.Equal(t, 0, cl.waiters.Len())
+}
+
+func TestConcurrencyLimiter_adaptive(t *testing.T) {
+	t.Parallel()
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		Limit: 4,
+		Adaptive: &AdaptiveConcurrencyConfig{
+			MinLimit:         2,
+			MaxLimit:         5,
+			LatencyThreshold: time.Second,
+			BackoffRatio:     0.5,
+		},
+	})
+	require.NoError(t, err)
+
+	acquire := func(n int) {
+		for i := 0; i < n; i++ {
+			_, ok := cl.acquire(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+			require.True(t, ok)
+		}
+	}
+
+	// low utilization does not increase the limit
+	acquire(1)
+	cl.release(false)
+	require.Equal(t, 4, cl.Limit())
+
+	// high utilization increases the limit up to the maximum
+	acquire(3)
+	cl.release(false)
+	require.Equal(t, 5, cl.Limit())
+	cl.release(false)
+	require.Equal(t, 5, cl.Limit())
+	cl.release(false)
+
+	// overload decreases the limit down to the minimum
+	acquire(1)
+	cl.release(true)
+	require.Equal(t, 2, cl.Limit())
+	acquire(1)
+	cl.release(true)
+	require.Equal(t, 2, cl.Limit())
+	require.Equal(t, 0, cl.InFlight())
+}
+
+func TestConcurrencyLimiter_Middleware_adaptive(t *testing.T) {
+	t.Parallel()
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		Limit:    4,
+		Adaptive: &AdaptiveConcurrencyConfig{LatencyThreshold: time.Minute},
+	})
+	require.NoError(t, err)
+
+	h := cl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	// a server error is considered an overload signal
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, 3, cl.Limit())
+	require.Equal(t, 0, cl.InFlight())
+}
+
+func TestConcurrencyLimiter_adaptiveBurst(t *testing.T) {
+	t.Parallel()
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		Limit:    10,
+		Adaptive: &AdaptiveConcurrencyConfig{LatencyThreshold: time.Minute},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, ok := cl.acquire(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+		require.True(t, ok)
+	}
+
+	// a burst of slow requests decreases the limit only once per latency window
+	for i := 0; i < 5; i++ {
+		cl.release(true)
+	}
+
+	require.Equal(t, 9, cl.Limit())
+	require.Equal(t, 0, cl.InFlight())
+}
+
+func TestConcurrencyLimiter_gauges(t *testing.T) {
+	t.Parallel()
+
+	m := &testGaugeMetrics{gauges: make(map[string]float64)}
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		Name:     "api",
+		Limit:    4,
+		Adaptive: &AdaptiveConcurrencyConfig{LatencyThreshold: time.Minute},
+		Metrics:  m,
+	})
+	require.NoError(t, err)
+	require.Equal(t, float64(4), m.gauge("concurrency:api:limit"))
+	require.Equal(t, float64(0), m.gauge("concurrency:api:in_flight"))
+
+	_, ok := cl.acquire(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+	require.True(t, ok)
+	require.Equal(t, float64(1), m.gauge("concurrency:api:in_flight"))
+
+	cl.release(true)
+	require.Equal(t, float64(3), m.gauge("concurrency:api:limit"))
+	require.Equal(t, float64(0), m.gauge("concurrency:api:in_flight"))
+}
@@ -3,6 +3,7 @@ package httpserver
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"math"
 	"net/http"
@@ -10,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/openapi"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
 	"github.com/nexmoinc/gosrvlib/pkg/ipify"
 	"github.com/nexmoinc/gosrvlib/pkg/profiling"
@@ -43,14 +46,26 @@ type config struct {
 	serverWriteTimeout      time.Duration
 	shutdownTimeout         time.Duration
 	tlsConfig               *tls.Config
+	clientCAs               *x509.CertPool
+	h2c                     bool
 	instrumentHandler       InstrumentHandler
+	middlewares             []Middleware
+	routeTimeout            time.Duration
+	deadlineHeaderName      string
+	accessLog               *AccessLogConfig
 	defaultEnabledRoutes    []defaultRoute
+	adminEnabledRoutes      []defaultRoute
 	indexHandlerFunc        IndexHandlerFunc
+	openAPIInfo             openapi.Info
 	ipHandlerFunc           http.HandlerFunc
 	metricsHandlerFunc      http.HandlerFunc
 	pingHandlerFunc         http.HandlerFunc
 	pprofHandlerFunc        http.HandlerFunc
 	statusHandlerFunc       http.HandlerFunc
+	logLevelHandlerFunc     http.HandlerFunc
+	configData              interface{}
+	adminAuthFunc           AdminAuthFunc
+	readiness               *healthcheck.Readiness
 	traceIDHeaderName       string
 	redactFn                RedactFn
 }
@@ -67,19 +82,25 @@ func defaultConfig() *config {
 		instrumentHandler:       defaultInstrumentHandler,
 		defaultEnabledRoutes:    nil,
 		indexHandlerFunc:        defaultIndexHandler,
+		openAPIInfo:             openapi.Info{Title: "API", Version: "1.0.0"},
 		ipHandlerFunc:           defaultIPHandler(GetPublicIPDefaultFunc()),
 		metricsHandlerFunc:      notImplementedHandler,
 		pingHandlerFunc:         defaultPingHandler,
 		pprofHandlerFunc:        profiling.PProfHandler,
 		statusHandlerFunc:       defaultStatusHandler,
+		logLevelHandlerFunc:     notImplementedHandler,
 		traceIDHeaderName:       traceid.DefaultHeader,
 		redactFn:                redact.HTTPData,
 	}
 }
 
 func (c *config) isIndexRouteEnabled() bool {
-	for _, r := range c.defaultEnabledRoutes {
-		if r == IndexRoute {
+	return c.isDefaultRouteEnabled(IndexRoute)
+}
+
+func (c *config) isDefaultRouteEnabled(id defaultRoute) bool {
+	for _, r := range c.enabledRoutes() {
+		if r == id {
 			return true
 		}
 	}
@@ -87,6 +108,23 @@ func (c *config) isIndexRouteEnabled() bool {
 	return false
 }
 
+// enabledRoutes returns the enabled default routes followed by the enabled admin routes, without duplicates.
+func (c *config) enabledRoutes() []defaultRoute {
+	ids := make([]defaultRoute, 0, len(c.defaultEnabledRoutes)+len(c.adminEnabledRoutes))
+	seen := make(map[defaultRoute]bool, cap(ids))
+
+	for _, list := range [][]defaultRoute{c.defaultEnabledRoutes, c.adminEnabledRoutes} {
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
 // validate the configuration.
 // nolint: gocyclo
 func (c *config) validate() error {
@@ -130,11 +168,71 @@ func (c *config) validate() error {
 		return fmt.Errorf("router is required")
 	}
 
+	if c.clientCAs != nil && c.tlsConfig == nil {
+		return fmt.Errorf("mutual TLS requires a TLS certificate")
+	}
+
+	if c.adminAuthFunc == nil && c.isDefaultRouteEnabled(ConfigRoute) {
+		return fmt.Errorf("the config route requires an admin auth function")
+	}
+
 	return nil
 }
 
+// setupAccessLog adds the access log as the outermost global middleware.
+func (c *config) setupAccessLog() {
+	if c.accessLog == nil {
+		return
+	}
+
+	c.middlewares = append([]Middleware{AccessLogMiddleware(*c.accessLog)}, c.middlewares...)
+}
+
+// bindRoute binds the handler to the router with the instrumentation handler.
+// A zero timeout is replaced by the default route timeout,
+// while a negative timeout also removes the server WriteTimeout (streaming routes).
+func (c *config) bindRoute(method, path string, timeout time.Duration, handler http.Handler) {
+	if timeout == 0 {
+		timeout = c.routeTimeout
+	}
+
+	if timeout < 0 {
+		handler = streamingHandler(handler)
+	}
+
+	handler = timeoutHandler(timeout, c.deadlineHeaderName, handler)
+
+	c.router.Handler(method, path, c.instrumentHandler(path, accessLogRouteHandler(path, handler)))
+}
+
+// setupMutualTLS enables the client certificate verification and the client identity injection.
+// The TLS configuration is cloned instead of being modified in place, as it could be shared.
+// The client identity middleware is the first global middleware, so the identity is included in the access log.
+func (c *config) setupMutualTLS() {
+	if c.clientCAs == nil {
+		return
+	}
+
+	c.tlsConfig = c.tlsConfig.Clone()
+	c.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	c.tlsConfig.ClientCAs = c.clientCAs
+	c.middlewares = append([]Middleware{clientIdentityMiddleware}, c.middlewares...)
+}
+
 // validateAddr checks if a http server bind address is valid.
 func validateAddr(addr string) error {
+	if strings.HasPrefix(addr, UnixAddrPrefix) {
+		if strings.TrimPrefix(addr, UnixAddrPrefix) == "" {
+			return fmt.Errorf("invalid http server unix socket address: %s", addr)
+		}
+
+		return nil
+	}
+
+	if strings.HasPrefix(addr, SystemdAddrPrefix) {
+		return nil
+	}
+
 	if !strings.Contains(addr, ":") {
 		return fmt.Errorf("invalid http server address: %s", addr)
 	}
@@ -154,7 +252,7 @@ func validateAddr(addr string) error {
 		return fmt.Errorf("invalid http server address: %s", addr)
 	}
 
-	if portInt < 1 || portInt > math.MaxUint16 {
+	if portInt < 0 || portInt > math.MaxUint16 {
 		return fmt.Errorf("invalid http server address: %s", addr)
 	}
 
@@ -1,6 +1,10 @@
 package httpserver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/nexmoinc/gosrvlib/pkg/testutil"
@@ -102,6 +106,31 @@ func Test_config_validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "fail with mutual TLS without TLS certificate",
+			setupConfig: func(cfg *config) {
+				cfg.router = defaultRouter(testutil.Context(), traceid.DefaultHeader, cfg.redactFn, cfg.instrumentHandler)
+				cfg.clientCAs = x509.NewCertPool()
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail with config route without admin auth function",
+			setupConfig: func(cfg *config) {
+				cfg.router = defaultRouter(testutil.Context(), traceid.DefaultHeader, cfg.redactFn, cfg.instrumentHandler)
+				cfg.adminEnabledRoutes = allAdminRoutes()
+			},
+			wantErr: true,
+		},
+		{
+			name: "succeed with config route and admin auth function",
+			setupConfig: func(cfg *config) {
+				cfg.router = defaultRouter(testutil.Context(), traceid.DefaultHeader, cfg.redactFn, cfg.instrumentHandler)
+				cfg.defaultEnabledRoutes = []defaultRoute{ConfigRoute}
+				cfg.adminAuthFunc = func(r *http.Request) error { return nil }
+			},
+			wantErr: false,
+		},
 		{
 			name: "succeed with valid configuration",
 			setupConfig: func(cfg *config) {
@@ -175,6 +204,26 @@ func Test_validateAddr(t *testing.T) {
 			addr:    "0.0.0.0:8017",
 			wantErr: false,
 		},
+		{
+			name:    "invalid unix socket address",
+			addr:    "unix://",
+			wantErr: true,
+		},
+		{
+			name:    "valid unix socket address",
+			addr:    "unix:///run/test.sock",
+			wantErr: false,
+		},
+		{
+			name:    "valid systemd address",
+			addr:    "systemd://public",
+			wantErr: false,
+		},
+		{
+			name:    "valid address (system assigned port)",
+			addr:    "127.0.0.1:0",
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -224,3 +273,60 @@ func Test_config_isIndexRouteEnabled(t *testing.T) {
 		})
 	}
 }
+
+func Test_config_isDefaultRouteEnabled(t *testing.T) {
+	t.Parallel()
+
+	c := &config{
+		defaultEnabledRoutes: []defaultRoute{OpenAPIRoute, PingRoute},
+	}
+
+	require.True(t, c.isDefaultRouteEnabled(OpenAPIRoute))
+	require.True(t, c.isDefaultRouteEnabled(PingRoute))
+	require.False(t, c.isDefaultRouteEnabled(IndexRoute))
+
+	c.adminEnabledRoutes = allAdminRoutes()
+
+	require.True(t, c.isDefaultRouteEnabled(ConfigRoute))
+}
+
+func Test_config_setupAccessLog(t *testing.T) {
+	t.Parallel()
+
+	mw := func(next http.Handler) http.Handler { return next }
+
+	c := &config{middlewares: []Middleware{mw}}
+	c.setupAccessLog()
+	require.Len(t, c.middlewares, 1)
+
+	c.accessLog = &AccessLogConfig{}
+	c.setupAccessLog()
+	require.Len(t, c.middlewares, 2)
+	require.Equal(t, reflect.ValueOf(mw).Pointer(), reflect.ValueOf(c.middlewares[1]).Pointer())
+}
+
+func Test_config_setupMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	mw := func(next http.Handler) http.Handler { return next }
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	c := &config{middlewares: []Middleware{mw}, tlsConfig: tlsConfig}
+	c.setupMutualTLS()
+	require.Len(t, c.middlewares, 1)
+	require.Same(t, tlsConfig, c.tlsConfig)
+
+	c.clientCAs = x509.NewCertPool()
+	c.accessLog = &AccessLogConfig{}
+	c.setupAccessLog()
+	c.setupMutualTLS()
+	require.Len(t, c.middlewares, 3)
+	require.Equal(t, reflect.ValueOf(clientIdentityMiddleware).Pointer(), reflect.ValueOf(c.middlewares[0]).Pointer())
+	require.Equal(t, tls.RequireAndVerifyClientCert, c.tlsConfig.ClientAuth)
+	require.Equal(t, c.clientCAs, c.tlsConfig.ClientCAs)
+
+	// the TLS configuration of the caller is not modified
+	require.NotSame(t, tlsConfig, c.tlsConfig)
+	require.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+	require.Nil(t, tlsConfig.ClientCAs)
+}
@@ -0,0 +1,197 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+)
+
+const corsWildcard = "*"
+
+// CORSConfig contains the Cross-Origin Resource Sharing (CORS) settings.
+type CORSConfig struct {
+	// AllowedOrigins is the list of origins allowed to make cross-origin requests (e.g. "https://example.com").
+	// The "*" value allows all origins.
+	AllowedOrigins []string
+
+	// AllowedMethods is the list of allowed methods for the preflight requests.
+	// The default methods are GET, HEAD and POST.
+	AllowedMethods []string
+
+	// AllowedHeaders is the list of allowed request headers for the preflight requests.
+	// The "*" value or an empty list allow all the requested headers.
+	AllowedHeaders []string
+
+	// ExposedHeaders is the list of response headers that can be accessed by the client.
+	ExposedHeaders []string
+
+	// AllowCredentials indicates whether the request can include user credentials (cookies, authorization headers or TLS client certificates).
+	AllowCredentials bool
+
+	// MaxAge indicates how long the results of a preflight request can be cached.
+	// A zero value does not set the header.
+	MaxAge time.Duration
+}
+
+// CORSMiddleware returns a middleware that handles the CORS headers and preflight requests.
+// Preflight requests are answered directly with 204 No Content, or with 403 Forbidden if not allowed.
+func CORSMiddleware(cfg CORSConfig) Middleware {
+	c := newCORS(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.handlePreflight(w, r, origin)
+				return
+			}
+
+			if c.isOriginAllowed(origin) {
+				c.setOriginHeaders(w, origin)
+
+				if c.exposedHeaders != "" {
+					w.Header().Set("Access-Control-Expose-Headers", c.exposedHeaders)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type cors struct {
+	allowAllOrigins  bool
+	allowAllHeaders  bool
+	allowCredentials bool
+	origins          map[string]struct{}
+	methods          map[string]struct{}
+	headers          map[string]struct{}
+	allowedMethods   string
+	exposedHeaders   string
+	maxAge           string
+}
+
+func newCORS(cfg CORSConfig) *cors {
+	c := &cors{
+		allowCredentials: cfg.AllowCredentials,
+		origins:          make(map[string]struct{}, len(cfg.AllowedOrigins)),
+		methods:          make(map[string]struct{}, len(cfg.AllowedMethods)),
+		headers:          make(map[string]struct{}, len(cfg.AllowedHeaders)),
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowAllHeaders:  len(cfg.AllowedHeaders) == 0,
+	}
+
+	for _, o := range cfg.AllowedOrigins {
+		if o == corsWildcard {
+			c.allowAllOrigins = true
+		}
+
+		c.origins[strings.ToLower(o)] = struct{}{}
+	}
+
+	allowedMethods := cfg.AllowedMethods
+	if len(allowedMethods) == 0 {
+		allowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	// the caller's slice is not modified
+	methods := make([]string, len(allowedMethods))
+
+	for i, m := range allowedMethods {
+		methods[i] = strings.ToUpper(m)
+		c.methods[methods[i]] = struct{}{}
+	}
+
+	c.allowedMethods = strings.Join(methods, ", ")
+
+	for _, h := range cfg.AllowedHeaders {
+		if h == corsWildcard {
+			c.allowAllHeaders = true
+		}
+
+		c.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return c
+}
+
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	reqMethod := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+
+	if !c.isOriginAllowed(origin) || !c.isMethodAllowed(reqMethod) || !c.areHeadersAllowed(reqHeaders) {
+		httputil.SendStatus(r.Context(), w, http.StatusForbidden)
+		return
+	}
+
+	c.setOriginHeaders(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", c.allowedMethods)
+
+	if reqHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", reqHeaders)
+	}
+
+	if c.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", c.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) setOriginHeaders(w http.ResponseWriter, origin string) {
+	if c.allowAllOrigins && !c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", corsWildcard)
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) isOriginAllowed(origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+
+	_, ok := c.origins[strings.ToLower(origin)]
+
+	return ok
+}
+
+func (c *cors) isMethodAllowed(method string) bool {
+	_, ok := c.methods[method]
+	return ok
+}
+
+func (c *cors) areHeadersAllowed(headers string) bool {
+	if c.allowAllHeaders || headers == "" {
+		return true
+	}
+
+	for _, h := range strings.Split(headers, ",") {
+		if _, ok := c.headers[http.CanonicalHeaderKey(strings.TrimSpace(h))]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,210 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		cfg          CORSConfig
+		method       string
+		reqHeaders   map[string]string
+		wantStatus   int
+		wantNext     bool
+		wantHeaders  map[string]string
+		emptyHeaders []string
+	}{
+		{
+			name:         "no origin",
+			cfg:          CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method:       http.MethodGet,
+			wantStatus:   http.StatusOK,
+			wantNext:     true,
+			emptyHeaders: []string{"Access-Control-Allow-Origin", "Vary"},
+		},
+		{
+			name:   "allowed origin",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}, ExposedHeaders: []string{"X-Alpha", "X-Beta"}},
+			method: http.MethodGet,
+			reqHeaders: map[string]string{
+				"Origin": "https://example.com",
+			},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://example.com",
+				"Access-Control-Expose-Headers": "X-Alpha, X-Beta",
+				"Vary":                          "Origin",
+			},
+			emptyHeaders: []string{"Access-Control-Allow-Credentials"},
+		},
+		{
+			name:   "wildcard origin",
+			cfg:    CORSConfig{AllowedOrigins: []string{"*"}},
+			method: http.MethodGet,
+			reqHeaders: map[string]string{
+				"Origin": "https://example.com",
+			},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+		},
+		{
+			name:   "wildcard origin with credentials",
+			cfg:    CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method: http.MethodGet,
+			reqHeaders: map[string]string{
+				"Origin": "https://example.com",
+			},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:   "disallowed origin",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method: http.MethodGet,
+			reqHeaders: map[string]string{
+				"Origin": "https://example.org",
+			},
+			wantStatus:   http.StatusOK,
+			wantNext:     true,
+			emptyHeaders: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name: "preflight",
+			cfg: CORSConfig{
+				AllowedOrigins: []string{"https://example.com"},
+				AllowedMethods: []string{"get", "put"},
+				AllowedHeaders: []string{"Content-Type", "X-Api-Key"},
+				MaxAge:         10 * time.Minute,
+			},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "PUT",
+				"Access-Control-Request-Headers": "content-type, x-api-key",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://example.com",
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": "content-type, x-api-key",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "preflight with any header",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "GET, HEAD, POST",
+				"Access-Control-Allow-Headers": "X-Custom",
+			},
+			emptyHeaders: []string{"Access-Control-Max-Age"},
+		},
+		{
+			name:   "preflight disallowed origin",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin":                        "https://example.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus:   http.StatusForbidden,
+			emptyHeaders: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name:   "preflight disallowed method",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "preflight disallowed header",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}, AllowedHeaders: []string{"Content-Type"}},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "Content-Type, X-Secret",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "options without preflight",
+			cfg:    CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			method: http.MethodOptions,
+			reqHeaders: map[string]string{
+				"Origin": "https://example.com",
+			},
+			wantStatus: http.StatusOK,
+			wantNext:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var called bool
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.reqHeaders {
+				req.Header.Set(k, v)
+			}
+
+			rr := httptest.NewRecorder()
+			CORSMiddleware(tt.cfg)(next).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			require.Equal(t, tt.wantNext, called)
+
+			for k, v := range tt.wantHeaders {
+				require.Equal(t, v, rr.Header().Get(k), k)
+			}
+
+			for _, k := range tt.emptyHeaders {
+				require.Empty(t, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_allowedMethodsNotModified(t *testing.T) {
+	t.Parallel()
+
+	methods := []string{"get", "put"}
+
+	_ = CORSMiddleware(CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: methods})
+
+	require.Equal(t, []string{"get", "put"}, methods)
+}
@@ -3,17 +3,22 @@ package httpserver
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"runtime/debug"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/openapi"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
 	"github.com/nexmoinc/gosrvlib/pkg/httputil"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Router is the interface representing the router used by the HTTP http server.
@@ -39,15 +44,39 @@ type nopBinder struct{}
 
 func (b *nopBinder) BindHTTP(_ context.Context) []route.Route { return nil }
 
+// Server is a running HTTP server.
+type Server struct {
+	cfg          *config
+	httpServer   *http.Server
+	listener     net.Listener
+	logger       *zap.Logger
+	errCh        chan error
+	done         chan struct{}
+	shutdownOnce sync.Once
+	mu           sync.Mutex
+	stopped      bool
+	serveErr     error
+	shutdownErr  error
+}
+
 // Start configures and start a new HTTP http server.
+// The server is shut down when the context is canceled.
+// This is a compatibility wrapper of NewServer that discards the server handle.
 func Start(ctx context.Context, binder Binder, opts ...Option) error {
+	_, err := NewServer(ctx, binder, opts...)
+	return err
+}
+
+// NewServer configures and starts a new HTTP server, returning the server handle.
+// The server is shut down when the context is canceled or when the Shutdown method is called.
+func NewServer(ctx context.Context, binder Binder, opts ...Option) (*Server, error) {
 	l := logging.WithComponent(ctx, "httpserver")
 
 	cfg := defaultConfig()
 
 	for _, applyOpt := range opts {
 		if err := applyOpt(cfg); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -56,9 +85,12 @@ func Start(ctx context.Context, binder Binder, opts ...Option) error {
 	}
 
 	if err := cfg.validate(); err != nil {
-		return err
+		return nil, err
 	}
 
+	cfg.setupAccessLog()
+	cfg.setupMutualTLS()
+
 	l.Debug("adding default routes")
 
 	routes := newDefaultRoutes(cfg)
@@ -72,83 +104,167 @@ func Start(ctx context.Context, binder Binder, opts ...Option) error {
 
 	for _, r := range routes {
 		l.Debug("binding route", zap.String("path", r.Path))
-		cfg.router.Handler(r.Method, r.Path, cfg.instrumentHandler(r.Path, r.Handler))
+		handler := ApplyMiddleware(r.Handler, r.Middlewares...)
+		cfg.bindRoute(r.Method, r.Path, r.Timeout, handler)
 	}
 
 	// attach route index if enabled
 	if cfg.isIndexRouteEnabled() {
 		l.Debug("enabling route index handler")
-		cfg.router.Handler(http.MethodGet, indexPath, cfg.instrumentHandler(indexPath, cfg.indexHandlerFunc(routes)))
+		cfg.bindRoute(http.MethodGet, indexPath, 0, cfg.indexHandlerFunc(routes))
+	}
+
+	// attach OpenAPI document if enabled
+	if cfg.isDefaultRouteEnabled(OpenAPIRoute) {
+		l.Debug("enabling OpenAPI document handler")
+		cfg.bindRoute(http.MethodGet, openAPIHandlerPath, 0, openAPIHandler(cfg.openAPIInfo, routes))
 	}
 
 	// wrap router with default middlewares
 	return startServer(ctx, cfg)
 }
 
-func startServer(ctx context.Context, cfg *config) error {
+func startServer(ctx context.Context, cfg *config) (*Server, error) {
 	l := logging.FromContext(ctx)
 
+	var handler http.Handler = RequestInjectHandler(l, cfg.traceIDHeaderName, cfg.redactFn, ApplyMiddleware(cfg.router, cfg.middlewares...))
+
+	if cfg.h2c {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
 	// create and start the http server
 	s := &http.Server{
 		Addr:              cfg.serverAddr,
-		Handler:           RequestInjectHandler(l, cfg.traceIDHeaderName, cfg.redactFn, cfg.router),
+		Handler:           handler,
 		ReadHeaderTimeout: cfg.serverReadHeaderTimeout,
 		ReadTimeout:       cfg.serverReadTimeout,
 		TLSConfig:         cfg.tlsConfig,
 		WriteTimeout:      cfg.serverWriteTimeout,
+		ConnContext:       withConn,
 	}
 
 	// start HTTP listener
-	var (
-		ls  net.Listener
-		err error
-	)
-
-	if cfg.tlsConfig == nil {
-		ls, err = net.Listen("tcp", cfg.serverAddr)
-	} else {
-		ls, err = tls.Listen("tcp", cfg.serverAddr, cfg.tlsConfig)
+	ls, err := listen(cfg.serverAddr, cfg.tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed creting the address listener: %w", err)
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed creting the address listener: %w", err)
+	srv := &Server{
+		cfg:        cfg,
+		httpServer: s,
+		listener:   ls,
+		logger:     l,
+		errCh:      make(chan error, 1),
+		done:       make(chan struct{}),
 	}
 
-	l.Info("listening for HTTP requests", zap.String("addr", cfg.serverAddr))
+	l.Info("listening for HTTP requests", zap.String("addr", ls.Addr().String()))
+
+	go srv.serve()
 
 	go func() {
-		if err := s.Serve(ls); err != nil {
-			l.Error("unexpected HTTP server failure", zap.Error(err))
+		select {
+		case <-srv.done:
+			return
+		case <-ctx.Done():
 		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+		defer cancel()
+
+		_ = srv.Shutdown(shutdownCtx)
 	}()
 
-	go func() {
-		<-ctx.Done()
+	return srv, nil
+}
 
-		l.Debug("shutting down HTTP http server")
+func (s *Server) serve() {
+	err := s.httpServer.Serve(s.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return
+	}
 
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	s.logger.Error("unexpected HTTP server failure", zap.Error(err))
 
-		defer cancel()
+	s.mu.Lock()
+	s.serveErr = err
 
-		_ = s.Shutdown(shutdownCtx)
+	if !s.stopped {
+		s.errCh <- err // buffered channel
+	}
 
-		l.Debug("HTTP server shutdown")
-	}()
+	s.mu.Unlock()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.shutdownTimeout)
+	defer cancel()
+
+	_ = s.Shutdown(shutdownCtx)
+}
+
+// Addr returns the address the server is listening on.
+// This is useful to get the port assigned by the system when the server address port is 0.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
 
-	return nil
+// Errors returns a channel that receives the unexpected server failure.
+// The channel is closed when the server stops.
+func (s *Server) Errors() <-chan error {
+	return s.errCh
 }
 
+// Shutdown gracefully shuts down the server without interrupting the active connections, within the context deadline.
+// Subsequent calls have no effect and return the result of the first call.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		s.logger.Debug("shutting down HTTP http server")
+
+		err := s.httpServer.Shutdown(ctx)
+
+		s.mu.Lock()
+		s.shutdownErr = err
+		s.stopped = true
+		close(s.errCh)
+		s.mu.Unlock()
+
+		close(s.done)
+
+		s.logger.Debug("HTTP server shutdown")
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.shutdownErr //nolint:wrapcheck
+}
+
+// Wait blocks until the server is completely shut down.
+// It returns the unexpected server failure and the shutdown error, if any.
+func (s *Server) Wait() error {
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return multierr.Combine(s.serveErr, s.shutdownErr)
+}
+
+// defaultRouter returns a router that responds with RFC 7807 problem details for 404, 405 and panic.
 func defaultRouter(ctx context.Context, traceIDHeaderName string, redactFn RedactFn, instrumentHandler InstrumentHandler) *httprouter.Router {
 	r := httprouter.New()
 	l := logging.FromContext(ctx)
 
 	r.NotFound = RequestInjectHandler(l, traceIDHeaderName, redactFn, instrumentHandler("404", func(w http.ResponseWriter, r *http.Request) {
-		httputil.SendStatus(r.Context(), w, http.StatusNotFound)
+		p := httputil.NewProblem(http.StatusNotFound, "invalid endpoint")
+		p.Instance = r.URL.Path
+		httputil.SendProblem(r.Context(), w, p)
 	}))
 
 	r.MethodNotAllowed = RequestInjectHandler(l, traceIDHeaderName, redactFn, instrumentHandler("405", func(w http.ResponseWriter, r *http.Request) {
-		httputil.SendStatus(r.Context(), w, http.StatusMethodNotAllowed)
+		p := httputil.NewProblem(http.StatusMethodNotAllowed, "the request cannot be routed")
+		p.Instance = r.URL.Path
+		httputil.SendProblem(r.Context(), w, p)
 	}))
 
 	r.PanicHandler = func(w http.ResponseWriter, r *http.Request, p interface{}) {
@@ -157,7 +273,7 @@ func defaultRouter(ctx context.Context, traceIDHeaderName string, redactFn Redac
 				zap.Any("err", p),
 				zap.String("stacktrace", string(debug.Stack())),
 			)
-			httputil.SendStatus(r.Context(), w, http.StatusInternalServerError)
+			httputil.SendProblem(r.Context(), w, httputil.NewProblem(http.StatusInternalServerError, "internal error"))
 		})).ServeHTTP(w, r)
 	}
 
@@ -171,6 +287,15 @@ func defaultIndexHandler(routes []route.Route) http.HandlerFunc {
 	}
 }
 
+// openAPIHandler serves the OpenAPI document generated once from the routes.
+func openAPIHandler(info openapi.Info, routes []route.Route) http.HandlerFunc {
+	doc := openapi.New(info, routes)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		httputil.SendJSON(r.Context(), w, http.StatusOK, doc)
+	}
+}
+
 func defaultIPHandler(fn GetPublicIPFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		status := http.StatusOK
@@ -14,7 +14,9 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/openapi"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
 	"github.com/nexmoinc/gosrvlib/pkg/redact"
 	"github.com/nexmoinc/gosrvlib/pkg/testutil"
 	"github.com/nexmoinc/gosrvlib/pkg/traceid"
@@ -97,6 +99,14 @@ func Test_defaultRouter(t *testing.T) {
 			}()
 
 			require.Equal(t, tt.wantStatus, resp.StatusCode, "status code got = %d, want = %d", resp.StatusCode, tt.wantStatus)
+			require.Equal(t, httputil.MimeApplicationProblemJSON, resp.Header.Get("Content-Type"))
+
+			var p httputil.Problem
+
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+			require.Equal(t, tt.wantStatus, p.Status)
+			require.Equal(t, http.StatusText(tt.wantStatus), p.Title)
+			require.Equal(t, httputil.ProblemTypeDefault, p.Type)
 		})
 	}
 }
@@ -140,6 +150,40 @@ func Test_defaultIndexHandler(t *testing.T) {
 	require.Equal(t, string(expBody)+"\n", string(body))
 }
 
+func Test_openAPIHandler(t *testing.T) {
+	t.Parallel()
+
+	info := openapi.Info{Title: "Test", Version: "1.0.0"}
+	routes := []route.Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/items/:id",
+			Description: "Get an item",
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/openapi.json", nil)
+	openAPIHandler(info, routes).ServeHTTP(rr, req)
+
+	resp := rr.Result() //nolint:bodyclose
+	require.NotNil(t, resp)
+
+	defer func() {
+		err := resp.Body.Close()
+		require.NoError(t, err, "error closing resp.Body")
+	}()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	expBody, _ := json.Marshal(openapi.New(info, routes))
+
+	require.Equal(t, string(expBody)+"\n", string(body))
+}
+
 func Test_defaultIPHandler(t *testing.T) {
 	t.Parallel()
 
@@ -396,3 +440,120 @@ YlAqGKDZ+A+l
 		})
 	}
 }
+
+func TestStart_middlewares(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	instrumentHandler := func(path string, handler http.HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "instrument")
+			handler(w, r)
+		})
+	}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBinder := NewMockBinder(mockCtrl)
+	mockBinder.EXPECT().BindHTTP(gomock.Any()).Return([]route.Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/test",
+			Middlewares: []Middleware{mw("route1"), mw("route2")},
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, "handler")
+			},
+		},
+	})
+
+	ctx, cancelCtx := context.WithCancel(testutil.Context())
+	defer cancelCtx()
+
+	s, err := NewServer(ctx, mockBinder,
+		WithServerAddr("127.0.0.1:0"),
+		WithInstrumentHandler(instrumentHandler),
+		WithMiddleware(mw("global1")),
+		WithMiddleware(mw("global2")),
+	)
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+"/test", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, []string{"global1", "global2", "instrument", "route1", "route2", "handler"}, calls)
+}
+
+func TestNewServer(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, NopBinder(),
+		WithServerAddr("127.0.0.1:0"),
+		WithEnableDefaultRoutes(PingRoute),
+	)
+	require.NoError(t, err)
+
+	addr, ok := s.Addr().(*net.TCPAddr)
+	require.True(t, ok)
+	require.NotZero(t, addr.Port)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+"/ping", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, s.Shutdown(ctx))
+	require.NoError(t, s.Shutdown(ctx))
+	require.NoError(t, s.Wait())
+
+	_, open := <-s.Errors()
+	require.False(t, open)
+
+	_, err = http.DefaultClient.Do(req)
+	require.Error(t, err)
+}
+
+func TestNewServer_contextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancelCtx := context.WithCancel(testutil.Context())
+
+	s, err := NewServer(ctx, NopBinder(), WithServerAddr("127.0.0.1:0"))
+	require.NoError(t, err)
+
+	cancelCtx()
+
+	require.NoError(t, s.Wait())
+}
+
+func TestNewServer_serveError(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewServer(testutil.Context(), NopBinder(), WithServerAddr("127.0.0.1:0"))
+	require.NoError(t, err)
+
+	// closing the listener makes the server fail unexpectedly
+	require.NoError(t, s.listener.Close())
+
+	require.Error(t, <-s.Errors())
+	require.Error(t, s.Wait())
+}
@@ -0,0 +1,428 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultIdempotencyKeyHeader is the default request header containing the idempotency key.
+	DefaultIdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is the response header set to "true" when the response is replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is the default retention time of the stored responses.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// DefaultIdempotencyLockTimeout is the default expiration time of the requests in progress.
+	DefaultIdempotencyLockTimeout = time.Minute
+
+	// DefaultIdempotencyMaxBodySize is the default maximum size of the request body in bytes.
+	DefaultIdempotencyMaxBodySize = 1 << 20
+
+	// idempotencyStoreTimeout is the timeout of the store operations executed after the request is served.
+	idempotencyStoreTimeout = 10 * time.Second
+
+	// idempotencySweepInterval is the minimum interval between the removal of the expired records from the memory store.
+	idempotencySweepInterval = time.Minute
+)
+
+var errIdempotencyBodyTooLarge = errors.New("the request body is too large")
+
+// idempotencySkippedHeaders are the response headers that are not stored,
+// as they depend on the connection or are set by the outer middlewares (e.g. compression).
+var idempotencySkippedHeaders = map[string]bool{
+	"Connection":          true,
+	"Content-Encoding":    true,
+	"Content-Length":      true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Vary":                true,
+}
+
+// IdempotencyRecord contains the state of a request identified by an idempotency key.
+type IdempotencyRecord struct {
+	// Fingerprint is the hash of the request method, URI and body.
+	Fingerprint string
+
+	// Completed is false while the first request is in progress.
+	Completed bool
+
+	// StatusCode is the HTTP status code of the stored response.
+	StatusCode int
+
+	// Header contains the headers of the stored response.
+	Header http.Header
+
+	// Body is the body of the stored response.
+	Body []byte
+}
+
+// IdempotencyStore is the interface of the storage of the idempotency records.
+// The default MemoryIdempotencyStore can be replaced by a shared store (e.g. SQLIdempotencyStore)
+// to enforce the idempotency across multiple instances.
+type IdempotencyStore interface {
+	// Lock atomically creates an in-progress record for the key, expiring after the timeout.
+	// If a non-expired record already exists, it is returned with locked set to false.
+	Lock(ctx context.Context, key, fingerprint string, timeout time.Duration) (rec *IdempotencyRecord, locked bool, err error)
+
+	// Save stores the completed record for the key, expiring after the TTL.
+	Save(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error
+
+	// Delete removes the record of the key.
+	Delete(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig contains the idempotency middleware settings.
+type IdempotencyConfig struct {
+	// Header is the request header containing the idempotency key.
+	// The default value is DefaultIdempotencyKeyHeader.
+	Header string
+
+	// Methods is the list of HTTP methods handled by the middleware.
+	// The default methods are POST, PUT, PATCH and DELETE.
+	Methods []string
+
+	// Required rejects the requests without the idempotency key with 400 Bad Request.
+	Required bool
+
+	// TTL is the retention time of the stored responses.
+	// The default value is DefaultIdempotencyTTL.
+	TTL time.Duration
+
+	// LockTimeout is the expiration time of the requests in progress (e.g. when the instance crashes).
+	// The default value is DefaultIdempotencyLockTimeout.
+	LockTimeout time.Duration
+
+	// MaxBodySize is the maximum size of the request body in bytes.
+	// Larger requests are rejected with 413 Request Entity Too Large.
+	// The default value is DefaultIdempotencyMaxBodySize.
+	MaxBodySize int64
+
+	// Store is the storage of the idempotency records.
+	// The default is a new MemoryIdempotencyStore.
+	Store IdempotencyStore
+}
+
+// IdempotencyMiddleware returns a middleware that makes the unsafe requests idempotent
+// when the client sends an idempotency key (e.g. on retries).
+//
+// The first response with a status code lower than 500 is stored and replayed for the repeated requests
+// with the same key and request fingerprint, adding the Idempotent-Replayed header.
+// The requests reusing a key with a different method, URI or body,
+// and the requests sent while the first one is still in progress, are rejected with 409 Conflict.
+// The server errors and the panics are not stored, so the request can be retried.
+// In case of store errors the requests are rejected with 503 Service Unavailable.
+//
+// Only the response headers set by the wrapped handler are stored, excluding the hop-by-hop headers,
+// Content-Encoding, Content-Length, Vary and RateLimit-*.
+// The uncompressed body is stored, so the CompressionMiddleware must wrap this middleware
+// (e.g. WithCompression), in order to compress the replayed responses again.
+func IdempotencyMiddleware(cfg IdempotencyConfig) Middleware {
+	if cfg.Header == "" {
+		cfg.Header = DefaultIdempotencyKeyHeader
+	}
+
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultIdempotencyTTL
+	}
+
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = DefaultIdempotencyLockTimeout
+	}
+
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = DefaultIdempotencyMaxBodySize
+	}
+
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryIdempotencyStore()
+	}
+
+	methods := make(map[string]struct{}, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		methods[m] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := methods[r.Method]; !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			key := r.Header.Get(cfg.Header)
+			if key == "" {
+				if cfg.Required {
+					httputil.SendStatus(ctx, w, http.StatusBadRequest)
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			fingerprint, err := requestFingerprint(r, cfg.MaxBodySize)
+			if errors.Is(err, errIdempotencyBodyTooLarge) {
+				httputil.SendStatus(ctx, w, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			if err != nil {
+				httputil.SendStatus(ctx, w, http.StatusBadRequest)
+				return
+			}
+
+			rec, locked, err := cfg.Store.Lock(ctx, key, fingerprint, cfg.LockTimeout)
+			if err != nil {
+				logging.FromContext(ctx).Error("idempotency store error", zap.Error(err))
+				httputil.SendStatus(ctx, w, http.StatusServiceUnavailable)
+
+				return
+			}
+
+			if !locked {
+				replayIdempotentResponse(ctx, w, rec, fingerprint)
+				return
+			}
+
+			serveIdempotent(w, r, next, &cfg, key, fingerprint)
+		})
+	}
+}
+
+// requestFingerprint returns the hash of the request method, URI and body, restoring the request body.
+func requestFingerprint(r *http.Request, maxBodySize int64) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed reading the request body: %w", err)
+	}
+
+	if int64(len(body)) > maxBodySize {
+		return "", errIdempotencyBodyTooLarge
+	}
+
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	_, _ = h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func replayIdempotentResponse(ctx context.Context, w http.ResponseWriter, rec *IdempotencyRecord, fingerprint string) {
+	if rec.Fingerprint != fingerprint || !rec.Completed {
+		httputil.SendStatus(ctx, w, http.StatusConflict)
+		return
+	}
+
+	h := w.Header()
+
+	for k, v := range rec.Header {
+		h[k] = append([]string(nil), v...)
+	}
+
+	h.Set(IdempotentReplayedHeader, "true")
+	h.Set("Content-Length", strconv.Itoa(len(rec.Body)))
+
+	w.WriteHeader(rec.StatusCode)
+	_, _ = w.Write(rec.Body)
+}
+
+// serveIdempotent executes the request and stores the response.
+// The record is saved or deleted with a context detached from the request,
+// as the request context is canceled when the client disconnects or times out, which is when the client retries.
+func serveIdempotent(w http.ResponseWriter, r *http.Request, next http.Handler, cfg *IdempotencyConfig, key, fingerprint string) {
+	ctx, cancel := context.WithTimeout(logging.WithLogger(context.Background(), logging.FromContext(r.Context())), idempotencyStoreTimeout)
+	defer cancel()
+
+	before := w.Header().Clone()
+
+	rw := httputil.NewResponseWriterWrapper(w)
+	body := &bytes.Buffer{}
+	rw.Tee(body)
+
+	stored := false
+
+	defer func() {
+		if stored {
+			return
+		}
+
+		// allow retries after server errors and panics
+		if err := cfg.Store.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Error("failed deleting the idempotency record", zap.Error(err))
+		}
+	}()
+
+	next.ServeHTTP(rw, r)
+
+	status := rw.Status()
+	if status == 0 {
+		status = http.StatusOK // nothing was written
+	}
+
+	if status >= http.StatusInternalServerError {
+		return
+	}
+
+	stored = true
+
+	rec := &IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		StatusCode:  status,
+		Header:      handlerHeader(before, w.Header()),
+		Body:        body.Bytes(),
+	}
+
+	if err := cfg.Store.Save(ctx, key, rec, cfg.TTL); err != nil {
+		logging.FromContext(ctx).Error("failed saving the idempotency record", zap.Error(err))
+	}
+}
+
+// handlerHeader returns a copy of the headers added or changed by the handler,
+// comparing the response headers with the ones set before calling the handler.
+func handlerHeader(before, after http.Header) http.Header {
+	h := make(http.Header, len(after))
+
+	for k, v := range after {
+		if idempotencySkippedHeaders[k] || strings.HasPrefix(k, "Ratelimit-") || equalHeaderValues(before[k], v) {
+			continue
+		}
+
+		h[k] = append([]string(nil), v...)
+	}
+
+	return h
+}
+
+func equalHeaderValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore for a single instance.
+// The expired records are periodically removed.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*memoryIdempotencyRecord
+	lastSweep time.Time
+	nowFn     func() time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates a new in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]*memoryIdempotencyRecord),
+		nowFn:   time.Now,
+	}
+}
+
+// Lock atomically creates an in-progress record for the key, expiring after the timeout.
+// If a non-expired record already exists, it is returned with locked set to false.
+func (s *MemoryIdempotencyStore) Lock(_ context.Context, key, fingerprint string, timeout time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFn()
+	s.sweep(now)
+
+	if r, ok := s.records[key]; ok && now.Before(r.expires) {
+		rec := r.rec
+		return &rec, false, nil
+	}
+
+	s.records[key] = &memoryIdempotencyRecord{
+		rec:     IdempotencyRecord{Fingerprint: fingerprint},
+		expires: now.Add(timeout),
+	}
+
+	return nil, true, nil
+}
+
+// Save stores the completed record for the key, expiring after the TTL.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = &memoryIdempotencyRecord{
+		rec:     *rec,
+		expires: s.nowFn().Add(ttl),
+	}
+
+	return nil
+}
+
+// Delete removes the record of the key.
+func (s *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// sweep removes the expired records.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		return
+	}
+
+	s.lastSweep = now
+
+	for k, r := range s.records {
+		if !now.Before(r.expires) {
+			delete(s.records, k)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package httpserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/sqlconn"
+)
+
+// DefaultIdempotencyTable is the default name of the SQL table containing the idempotency records.
+const DefaultIdempotencyTable = "idempotency"
+
+var regexSQLTableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// SQLIdempotencyStore is an IdempotencyStore shared across multiple instances using a SQL table.
+// The queries use the "?" placeholders (e.g. MySQL, SQLite).
+// The table must have the following structure (MySQL syntax):
+//
+//	CREATE TABLE idempotency (
+//		idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+//		fingerprint CHAR(64) NOT NULL,
+//		completed BOOLEAN NOT NULL,
+//		status_code INT NOT NULL,
+//		header BLOB NOT NULL,
+//		body LONGBLOB NOT NULL,
+//		expires_at BIGINT NOT NULL,
+//		KEY idx_expires_at (expires_at)
+//	);
+//
+// The expired records are replaced when the same key is used again,
+// and they can be periodically removed with the DeleteExpired method.
+type SQLIdempotencyStore struct {
+	conn     *sqlconn.SQLConn
+	nowFn    func() time.Time
+	sqlClean string
+	sqlLock  string
+	sqlGet   string
+	sqlSave  string
+	sqlDel   string
+	sqlPurge string
+}
+
+// NewSQLIdempotencyStore creates a new SQL idempotency store using the specified table.
+// The default table name is DefaultIdempotencyTable.
+func NewSQLIdempotencyStore(conn *sqlconn.SQLConn, table string) (*SQLIdempotencyStore, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("the SQL connection is required")
+	}
+
+	if table == "" {
+		table = DefaultIdempotencyTable
+	}
+
+	if !regexSQLTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid SQL table name: %q", table)
+	}
+
+	//nolint:gosec
+	return &SQLIdempotencyStore{
+		conn:     conn,
+		nowFn:    time.Now,
+		sqlClean: "DELETE FROM " + table + " WHERE idempotency_key = ? AND expires_at <= ?",
+		sqlLock:  "INSERT INTO " + table + " (idempotency_key, fingerprint, completed, status_code, header, body, expires_at) VALUES (?, ?, 0, 0, '', '', ?)",
+		sqlGet:   "SELECT fingerprint, completed, status_code, header, body FROM " + table + " WHERE idempotency_key = ? AND expires_at > ?",
+		sqlSave:  "UPDATE " + table + " SET fingerprint = ?, completed = 1, status_code = ?, header = ?, body = ?, expires_at = ? WHERE idempotency_key = ?",
+		sqlDel:   "DELETE FROM " + table + " WHERE idempotency_key = ?",
+		sqlPurge: "DELETE FROM " + table + " WHERE expires_at <= ?",
+	}, nil
+}
+
+// Lock atomically creates an in-progress record for the key, expiring after the timeout.
+// If a non-expired record already exists, it is returned with locked set to false.
+func (s *SQLIdempotencyStore) Lock(ctx context.Context, key, fingerprint string, timeout time.Duration) (*IdempotencyRecord, bool, error) {
+	db := s.conn.DB()
+	now := s.nowFn()
+
+	if _, err := db.ExecContext(ctx, s.sqlClean, key, now.UnixMilli()); err != nil {
+		return nil, false, fmt.Errorf("failed deleting the expired idempotency record: %w", err)
+	}
+
+	_, insErr := db.ExecContext(ctx, s.sqlLock, key, fingerprint, now.Add(timeout).UnixMilli())
+	if insErr == nil {
+		return nil, true, nil
+	}
+
+	// the insert fails when the key already exists
+	var (
+		rec    IdempotencyRecord
+		header []byte
+	)
+
+	err := db.QueryRowContext(ctx, s.sqlGet, key, now.UnixMilli()).Scan(&rec.Fingerprint, &rec.Completed, &rec.StatusCode, &header, &rec.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed inserting the idempotency record: %w", insErr)
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("failed reading the idempotency record: %w", err)
+	}
+
+	if len(header) > 0 {
+		if err := json.Unmarshal(header, &rec.Header); err != nil {
+			return nil, false, fmt.Errorf("failed decoding the idempotency record header: %w", err)
+		}
+	}
+
+	return &rec, false, nil
+}
+
+// Save stores the completed record for the key, expiring after the TTL.
+func (s *SQLIdempotencyStore) Save(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	header := []byte(`{}`)
+
+	if rec.Header != nil {
+		var err error
+
+		header, err = json.Marshal(rec.Header)
+		if err != nil {
+			return fmt.Errorf("failed encoding the idempotency record header: %w", err)
+		}
+	}
+
+	body := rec.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	_, err := s.conn.DB().ExecContext(ctx, s.sqlSave, rec.Fingerprint, rec.StatusCode, header, body, s.nowFn().Add(ttl).UnixMilli(), key)
+	if err != nil {
+		return fmt.Errorf("failed saving the idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the record of the key.
+func (s *SQLIdempotencyStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.conn.DB().ExecContext(ctx, s.sqlDel, key); err != nil {
+		return fmt.Errorf("failed deleting the idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes all the expired records and returns the number of deleted records.
+func (s *SQLIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.conn.DB().ExecContext(ctx, s.sqlPurge, s.nowFn().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed deleting the expired idempotency records: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed counting the expired idempotency records: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,225 @@
+package httpserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nexmoinc/gosrvlib/pkg/sqlconn"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLIdempotencyStore(t *testing.T) (*SQLIdempotencyStore, sqlmock.Sqlmock, time.Time) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(testutil.Context())
+	t.Cleanup(cancel)
+
+	conn, err := sqlconn.Connect(ctx, "sqlmock://test",
+		sqlconn.WithSQLOpenFunc(func(_, _ string) (*sql.DB, error) { return db, nil }),
+		sqlconn.WithCheckConnectionFunc(func(_ context.Context, _ *sql.DB) error { return nil }),
+	)
+	require.NoError(t, err)
+
+	s, err := NewSQLIdempotencyStore(conn, "")
+	require.NoError(t, err)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.nowFn = func() time.Time { return now }
+
+	return s, mock, now
+}
+
+func TestNewSQLIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSQLIdempotencyStore(nil, "")
+	require.Error(t, err)
+
+	conn := &sqlconn.SQLConn{}
+
+	_, err = NewSQLIdempotencyStore(conn, "idem; DROP TABLE users")
+	require.Error(t, err)
+
+	s, err := NewSQLIdempotencyStore(conn, "db.idem")
+	require.NoError(t, err)
+	require.Contains(t, s.sqlLock, "INSERT INTO db.idem ")
+}
+
+func TestSQLIdempotencyStore_Lock(t *testing.T) {
+	t.Parallel()
+
+	timeout := time.Minute
+	cols := []string{"fingerprint", "completed", "status_code", "header", "body"}
+
+	tests := []struct {
+		name       string
+		setupMocks func(mock sqlmock.Sqlmock, now int64)
+		wantRec    *IdempotencyRecord
+		wantLocked bool
+		wantErr    bool
+	}{
+		{
+			name: "locked",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WithArgs("key", now).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WithArgs("key", "fp", now+timeout.Milliseconds()).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantLocked: true,
+		},
+		{
+			name: "existing record",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WillReturnError(errors.New("duplicate key"))
+				mock.ExpectQuery("SELECT (.+) FROM idempotency").WithArgs("key", now).
+					WillReturnRows(sqlmock.NewRows(cols).AddRow("fp", true, 201, []byte(`{"X-Test":["a"]}`), []byte("ok")))
+			},
+			wantRec: &IdempotencyRecord{
+				Fingerprint: "fp",
+				Completed:   true,
+				StatusCode:  http.StatusCreated,
+				Header:      http.Header{"X-Test": []string{"a"}},
+				Body:        []byte("ok"),
+			},
+		},
+		{
+			name: "in progress record",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WillReturnError(errors.New("duplicate key"))
+				mock.ExpectQuery("SELECT (.+) FROM idempotency").
+					WillReturnRows(sqlmock.NewRows(cols).AddRow("fp", false, 0, []byte{}, []byte{}))
+			},
+			wantRec: &IdempotencyRecord{Fingerprint: "fp", Body: []byte{}},
+		},
+		{
+			name: "clean error",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "insert error",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WillReturnError(errors.New("db error"))
+				mock.ExpectQuery("SELECT (.+) FROM idempotency").WillReturnRows(sqlmock.NewRows(cols))
+			},
+			wantErr: true,
+		},
+		{
+			name: "select error",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WillReturnError(errors.New("duplicate key"))
+				mock.ExpectQuery("SELECT (.+) FROM idempotency").WillReturnError(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "header decoding error",
+			setupMocks: func(mock sqlmock.Sqlmock, now int64) {
+				mock.ExpectExec("DELETE FROM idempotency").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO idempotency").WillReturnError(errors.New("duplicate key"))
+				mock.ExpectQuery("SELECT (.+) FROM idempotency").
+					WillReturnRows(sqlmock.NewRows(cols).AddRow("fp", true, 200, []byte("{"), []byte{}))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, mock, now := newTestSQLIdempotencyStore(t)
+			tt.setupMocks(mock, now.UnixMilli())
+
+			rec, locked, err := s.Lock(testutil.Context(), "key", "fp", timeout)
+
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantLocked, locked)
+			require.Equal(t, tt.wantRec, rec)
+		})
+	}
+}
+
+func TestSQLIdempotencyStore_Save(t *testing.T) {
+	t.Parallel()
+
+	s, mock, now := newTestSQLIdempotencyStore(t)
+	ctx := testutil.Context()
+	expires := now.Add(time.Hour).UnixMilli()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency SET")).
+		WithArgs("fp", http.StatusOK, []byte(`{"X-Test":["a"]}`), []byte("ok"), expires, "key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := s.Save(ctx, "key", &IdempotencyRecord{
+		Fingerprint: "fp",
+		Completed:   true,
+		StatusCode:  http.StatusOK,
+		Header:      http.Header{"X-Test": []string{"a"}},
+		Body:        []byte("ok"),
+	}, time.Hour)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency SET")).
+		WithArgs("fp", http.StatusNoContent, []byte(`{}`), []byte{}, expires, "key").
+		WillReturnError(errors.New("db error"))
+
+	err = s.Save(ctx, "key", &IdempotencyRecord{Fingerprint: "fp", StatusCode: http.StatusNoContent}, time.Hour)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLIdempotencyStore_Delete(t *testing.T) {
+	t.Parallel()
+
+	s, mock, now := newTestSQLIdempotencyStore(t)
+	ctx := testutil.Context()
+
+	mock.ExpectExec("DELETE FROM idempotency WHERE idempotency_key").WithArgs("key").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.Delete(ctx, "key"))
+
+	mock.ExpectExec("DELETE FROM idempotency WHERE idempotency_key").WillReturnError(errors.New("db error"))
+	require.Error(t, s.Delete(ctx, "key"))
+
+	mock.ExpectExec("DELETE FROM idempotency WHERE expires_at").WithArgs(now.UnixMilli()).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	mock.ExpectExec("DELETE FROM idempotency WHERE expires_at").WillReturnError(errors.New("db error"))
+
+	_, err = s.DeleteExpired(ctx)
+	require.Error(t, err)
+
+	mock.ExpectExec("DELETE FROM idempotency WHERE expires_at").WillReturnResult(sqlmock.NewErrorResult(errors.New("rows error")))
+
+	_, err = s.DeleteExpired(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
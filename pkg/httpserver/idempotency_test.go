@@ -0,0 +1,456 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testIdempotencyStore struct {
+	lockErr   error
+	deleteErr error
+	saveErr   error
+}
+
+func (s *testIdempotencyStore) Lock(_ context.Context, _, _ string, _ time.Duration) (*IdempotencyRecord, bool, error) {
+	return nil, s.lockErr == nil, s.lockErr
+}
+
+func (s *testIdempotencyStore) Save(_ context.Context, _ string, _ *IdempotencyRecord, _ time.Duration) error {
+	return s.saveErr
+}
+
+func (s *testIdempotencyStore) Delete(_ context.Context, _ string) error {
+	return s.deleteErr
+}
+
+// testCtxIdempotencyStore is a memory store failing the operations with a done context, like the SQL store.
+type testCtxIdempotencyStore struct {
+	*MemoryIdempotencyStore
+}
+
+func (s *testCtxIdempotencyStore) Save(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return s.MemoryIdempotencyStore.Save(ctx, key, rec, ttl)
+}
+
+func (s *testCtxIdempotencyStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return s.MemoryIdempotencyStore.Delete(ctx, key)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func testIdempotencyRequest(t *testing.T, handler http.Handler, method, key, body string) *http.Response {
+	t.Helper()
+
+	req, _ := http.NewRequestWithContext(testutil.Context(), method, "/orders?x=1", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(DefaultIdempotencyKeyHeader, key)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	return rr.Result() //nolint:bodyclose
+}
+
+func testReadBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return string(b)
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-Call", string(rune('0'+n)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created:" + string(body)))
+	})
+
+	handler := IdempotencyMiddleware(IdempotencyConfig{})(next)
+
+	// first request
+	resp := testIdempotencyRequest(t, handler, http.MethodPost, "k1", "order")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "created:order", testReadBody(t, resp))
+	require.Empty(t, resp.Header.Get(IdempotentReplayedHeader))
+
+	// replayed request
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "k1", "order")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "created:order", testReadBody(t, resp))
+	require.Equal(t, "true", resp.Header.Get(IdempotentReplayedHeader))
+	require.Equal(t, "1", resp.Header.Get("X-Call"))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// conflicting body
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "k1", "other")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// different key
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "k2", "order")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// without key
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "", "order")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// safe method
+	resp = testIdempotencyRequest(t, handler, http.MethodGet, "k1", "")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware_compression(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	payload := strings.Repeat(`{"id":1}`, 200)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Order", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(payload))
+	})
+
+	// outer middleware setting a different header value on each request
+	var remaining int32 = 10
+
+	rateLimit := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(atomic.AddInt32(&remaining, -1))))
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	handler := rateLimit(newTestCompressionMiddleware(t, CompressionConfig{})(IdempotencyMiddleware(IdempotencyConfig{})(next)))
+
+	do := func() *http.Response {
+		req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodPost, "/orders", strings.NewReader("order"))
+		req.Header.Set(DefaultIdempotencyKeyHeader, "k1")
+		req.Header.Set("Accept-Encoding", EncodingGzip)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Result()
+	}
+
+	for i, want := range []string{"9", "8"} {
+		resp := do()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, EncodingGzip, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, "1", resp.Header.Get("X-Order"))
+		require.Equal(t, want, resp.Header.Get("RateLimit-Remaining"))
+		require.Equal(t, i == 1, resp.Header.Get(IdempotentReplayedHeader) == "true")
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		require.Equal(t, payload, string(body))
+	}
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func Test_handlerHeader(t *testing.T) {
+	t.Parallel()
+
+	before := http.Header{"X-Outer": {"a"}, "Vary": {"Accept-Encoding"}}
+	after := http.Header{
+		"X-Outer":          {"a"},
+		"X-Changed":        {"b"},
+		"Vary":             {"Accept-Encoding", "Origin"},
+		"Content-Encoding": {"gzip"},
+		"Content-Length":   {"10"},
+		"Connection":       {"close"},
+		"Ratelimit-Limit":  {"10"},
+		"Content-Type":     {"text/plain"},
+	}
+
+	h := handlerHeader(before, after)
+	require.Equal(t, http.Header{"X-Changed": {"b"}, "Content-Type": {"text/plain"}}, h)
+
+	// the values are copied
+	h["X-Changed"][0] = "c"
+	require.Equal(t, "b", after.Get("X-Changed"))
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware_inProgress(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := IdempotencyMiddleware(IdempotencyConfig{})(next)
+
+	done := make(chan *http.Response)
+
+	go func() {
+		done <- testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	}()
+
+	<-started
+
+	resp := testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	close(release)
+
+	resp = <-done
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware_serverError(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			panic("test")
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	handler := IdempotencyMiddleware(IdempotencyConfig{})(next)
+
+	resp := testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	// the server errors are not stored
+	require.Panics(t, func() {
+		testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	})
+
+	// the panics are not stored
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware_canceledRequest(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	ctx, cancel := context.WithCancel(testutil.Context())
+	defer cancel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		// the client disconnects before the handler returns
+		cancel()
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	})
+
+	handler := IdempotencyMiddleware(IdempotencyConfig{
+		Store: &testCtxIdempotencyStore{MemoryIdempotencyStore: NewMemoryIdempotencyStore()},
+	})(next)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/orders?x=1", strings.NewReader("data"))
+	req.Header.Set(DefaultIdempotencyKeyHeader, "key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the retry replays the stored response
+	resp := testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(IdempotentReplayedHeader))
+	require.Equal(t, "created", testReadBody(t, resp))
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+//nolint:bodyclose
+func TestIdempotencyMiddleware_errors(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// required key
+	handler := IdempotencyMiddleware(IdempotencyConfig{Required: true})(next)
+	resp := testIdempotencyRequest(t, handler, http.MethodPost, "", "data")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// body too large
+	handler = IdempotencyMiddleware(IdempotencyConfig{MaxBodySize: 3})(next)
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// body read error
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodPost, "/", errReader{})
+	req.Header.Set(DefaultIdempotencyKeyHeader, "key")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// store errors
+	handler = IdempotencyMiddleware(IdempotencyConfig{Store: &testIdempotencyStore{lockErr: errors.New("lock")}})(next)
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	handler = IdempotencyMiddleware(IdempotencyConfig{Store: &testIdempotencyStore{saveErr: errors.New("save")}})(next)
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	errNext := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler = IdempotencyMiddleware(IdempotencyConfig{Store: &testIdempotencyStore{deleteErr: errors.New("delete")}})(errNext)
+	resp = testIdempotencyRequest(t, handler, http.MethodPost, "key", "data")
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func Test_requestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	newReq := func(method, uri, body string) *http.Request {
+		var rd io.Reader = http.NoBody
+		if body != "" {
+			rd = strings.NewReader(body)
+		}
+
+		req, _ := http.NewRequestWithContext(testutil.Context(), method, uri, rd)
+
+		return req
+	}
+
+	req := newReq(http.MethodPost, "/a?b=1", "data")
+	fp1, err := requestFingerprint(req, 10)
+	require.NoError(t, err)
+	require.Len(t, fp1, 64)
+
+	// the body is restored
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(body))
+
+	fp2, err := requestFingerprint(newReq(http.MethodPost, "/a?b=1", "data"), 10)
+	require.NoError(t, err)
+	require.Equal(t, fp1, fp2)
+
+	for _, r := range []*http.Request{
+		newReq(http.MethodPut, "/a?b=1", "data"),
+		newReq(http.MethodPost, "/a?b=2", "data"),
+		newReq(http.MethodPost, "/a?b=1", "other"),
+		newReq(http.MethodPost, "/a?b=1", ""),
+	} {
+		fp, err := requestFingerprint(r, 10)
+		require.NoError(t, err)
+		require.NotEqual(t, fp1, fp)
+	}
+
+	_, err = requestFingerprint(newReq(http.MethodPost, "/", "data"), 3)
+	require.ErrorIs(t, err, errIdempotencyBodyTooLarge)
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewMemoryIdempotencyStore()
+	s.nowFn = func() time.Time { return now }
+
+	rec, locked, err := s.Lock(ctx, "key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.True(t, locked)
+	require.Nil(t, rec)
+
+	rec, locked, err = s.Lock(ctx, "key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.False(t, locked)
+	require.Equal(t, &IdempotencyRecord{Fingerprint: "fp"}, rec)
+
+	saved := &IdempotencyRecord{Fingerprint: "fp", Completed: true, StatusCode: http.StatusOK, Body: []byte("ok")}
+	require.NoError(t, s.Save(ctx, "key", saved, time.Hour))
+
+	// the lock timeout is replaced by the TTL
+	now = now.Add(30 * time.Minute)
+
+	rec, locked, err = s.Lock(ctx, "key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.False(t, locked)
+	require.Equal(t, saved, rec)
+
+	// expired record
+	now = now.Add(time.Hour)
+
+	_, locked, err = s.Lock(ctx, "key", "fp2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	require.NoError(t, s.Delete(ctx, "key"))
+
+	_, locked, err = s.Lock(ctx, "key", "fp", time.Minute)
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	// sweep the expired records
+	_, _, _ = s.Lock(ctx, "other", "fp", time.Minute)
+	require.Len(t, s.records, 2)
+
+	now = now.Add(2 * time.Minute)
+
+	_, _, _ = s.Lock(ctx, "new", "fp", time.Minute)
+	require.Len(t, s.records, 1)
+}
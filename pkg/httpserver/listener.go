@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// UnixAddrPrefix is the server address prefix for Unix domain sockets (e.g. "unix:///run/service.sock").
+	UnixAddrPrefix = "unix://"
+
+	// SystemdAddrPrefix is the server address prefix for the listeners inherited via systemd socket activation.
+	// The "systemd://" address selects the first inherited listener,
+	// while "systemd://name" selects the listener with the matching FileDescriptorName.
+	SystemdAddrPrefix = "systemd://"
+
+	// systemdListenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START).
+	systemdListenFDsStart = 3
+)
+
+// listen creates the server listener for the specified address, with TLS if configured.
+func listen(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	var (
+		ls  net.Listener
+		err error
+	)
+
+	switch {
+	case strings.HasPrefix(addr, UnixAddrPrefix):
+		ls, err = listenUnix(strings.TrimPrefix(addr, UnixAddrPrefix))
+	case strings.HasPrefix(addr, SystemdAddrPrefix):
+		ls, err = systemdListener(strings.TrimPrefix(addr, SystemdAddrPrefix), os.Getenv, os.Getpid(), systemdListenFDsStart)
+	default:
+		ls, err = net.Listen("tcp", addr)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		ls = tls.NewListener(ls, tlsConfig)
+	}
+
+	return ls, nil
+}
+
+// listenUnix listens on a Unix domain socket, removing a stale socket file left by a previous process.
+func listenUnix(path string) (net.Listener, error) {
+	fi, err := os.Stat(path)
+
+	switch {
+	case err == nil && fi.Mode()&fs.ModeSocket != 0:
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed removing the stale unix socket: %w", err)
+		}
+	case err == nil:
+		return nil, fmt.Errorf("the unix socket path is not a socket: %s", path)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed checking the unix socket path: %w", err)
+	}
+
+	return net.Listen("unix", path) //nolint:wrapcheck
+}
+
+// systemdListener returns the listener inherited via systemd socket activation (see sd_listen_fds(3)).
+// An empty name selects the first listener.
+func systemdListener(name string, getenv func(string) string, pid, firstFD int) (net.Listener, error) {
+	if lpid, err := strconv.Atoi(getenv("LISTEN_PID")); err != nil || lpid != pid {
+		return nil, fmt.Errorf("no systemd listeners passed to this process")
+	}
+
+	nfds, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || nfds < 1 {
+		return nil, fmt.Errorf("no systemd listeners passed to this process")
+	}
+
+	names := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+
+	for i := 0; i < nfds; i++ {
+		fdName := ""
+		if i < len(names) {
+			fdName = names[i]
+		}
+
+		if name != "" && name != fdName {
+			continue
+		}
+
+		f := os.NewFile(uintptr(firstFD+i), fdName)
+
+		ls, err := net.FileListener(f)
+
+		_ = f.Close() // the listener uses a duplicated file descriptor
+
+		if err != nil {
+			return nil, fmt.Errorf("failed using the systemd listener %d: %w", i, err)
+		}
+
+		return ls, nil
+	}
+
+	return nil, fmt.Errorf("systemd listener not found: %s", name)
+}
@@ -0,0 +1,220 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func Test_listen(t *testing.T) {
+	t.Parallel()
+
+	ls, err := listen("127.0.0.1:0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "tcp", ls.Addr().Network())
+	require.NoError(t, ls.Close())
+
+	ls, err = listen("127.0.0.1:0", &tls.Config{MinVersion: tls.VersionTLS12})
+	require.NoError(t, err)
+	require.NoError(t, ls.Close())
+
+	_, err = listen("127.0.0.1:-1", nil)
+	require.Error(t, err)
+
+	_, err = listen(SystemdAddrPrefix, nil)
+	require.Error(t, err)
+}
+
+func Test_listenUnix(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sock")
+
+	ls, err := listen(UnixAddrPrefix+path, nil)
+	require.NoError(t, err)
+	require.Equal(t, "unix", ls.Addr().Network())
+
+	// leave a stale socket file
+	ul, ok := ls.(*net.UnixListener)
+	require.True(t, ok)
+	ul.SetUnlinkOnClose(false)
+	require.NoError(t, ls.Close())
+
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	ls, err = listenUnix(path)
+	require.NoError(t, err)
+	require.NoError(t, ls.Close())
+
+	// not a socket
+	filePath := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("test"), 0o600))
+
+	_, err = listenUnix(filePath)
+	require.Error(t, err)
+}
+
+func Test_systemdListener(t *testing.T) {
+	t.Parallel()
+
+	tcpLs, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = tcpLs.Close() })
+
+	tl, ok := tcpLs.(*net.TCPListener)
+	require.True(t, ok)
+
+	rc, err := tl.SyscallConn()
+	require.NoError(t, err)
+
+	pid := os.Getpid()
+
+	tests := []struct {
+		name    string
+		lsName  string
+		env     map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "missing environment",
+			env:     map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "different process",
+			env:     map[string]string{"LISTEN_PID": strconv.Itoa(pid + 1), "LISTEN_FDS": "1"},
+			wantErr: true,
+		},
+		{
+			name:    "no file descriptors",
+			env:     map[string]string{"LISTEN_PID": strconv.Itoa(pid), "LISTEN_FDS": "0"},
+			wantErr: true,
+		},
+		{
+			name:    "name not found",
+			lsName:  "public",
+			env:     map[string]string{"LISTEN_PID": strconv.Itoa(pid), "LISTEN_FDS": "1", "LISTEN_FDNAMES": "monitoring"},
+			wantErr: true,
+		},
+		{
+			name: "first listener",
+			env:  map[string]string{"LISTEN_PID": strconv.Itoa(pid), "LISTEN_FDS": "1"},
+		},
+		{
+			name:   "named listener",
+			lsName: "public",
+			env:    map[string]string{"LISTEN_PID": strconv.Itoa(pid), "LISTEN_FDS": "1", "LISTEN_FDNAMES": "public"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// duplicate the file descriptor as inherited from systemd
+			var (
+				fd     int
+				dupErr error
+			)
+
+			err := rc.Control(func(sfd uintptr) {
+				fd, dupErr = syscall.Dup(int(sfd))
+			})
+			require.NoError(t, err)
+			require.NoError(t, dupErr)
+
+			getenv := func(key string) string { return tt.env[key] }
+
+			ls, err := systemdListener(tt.lsName, getenv, pid, fd)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.NoError(t, syscall.Close(fd))
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tcpLs.Addr().String(), ls.Addr().String())
+			require.NoError(t, ls.Close())
+		})
+	}
+}
+
+func TestNewServer_unixSocket(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+	path := filepath.Join(t.TempDir(), "server.sock")
+
+	s, err := NewServer(ctx, NopBinder(),
+		WithServerAddr(UnixAddrPrefix+path),
+		WithEnableDefaultRoutes(PingRoute),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/ping", nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewServer_h2c(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, NopBinder(),
+		WithServerAddr("127.0.0.1:0"),
+		WithEnableDefaultRoutes(PingRoute),
+		WithH2C(),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+"/ping", nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, resp.ProtoMajor)
+}
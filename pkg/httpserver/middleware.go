@@ -4,12 +4,37 @@ import (
 	"net/http"
 	"net/http/httputil"
 
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
 	"github.com/nexmoinc/gosrvlib/pkg/logging"
 	"github.com/nexmoinc/gosrvlib/pkg/traceid"
 	"github.com/nexmoinc/gosrvlib/pkg/uidc"
 	"go.uber.org/zap"
 )
 
+// Middleware is a function that wraps an http.Handler to add functionalities before and after the request is handled.
+//
+// The handlers are executed in the following order:
+//
+//  1. RequestInjectHandler: injects the trace ID and the request-scoped logger in the request context;
+//  2. global middlewares set with WithMiddleware, in order, preceded by the access log set with WithAccessLog
+//     and by the client identity middleware set with WithMutualTLS (executed first to log the client identity);
+//  3. router;
+//  4. instrumentation handler set with WithInstrumentHandler;
+//  5. route timeout set in route.Route.Timeout or with WithRouteTimeout and WithDeadlineHeaderName;
+//  6. route middlewares set in route.Route.Middlewares, in order;
+//  7. route handler.
+type Middleware = route.Middleware
+
+// ApplyMiddleware wraps the handler with the specified middlewares.
+// The first middleware is the outermost one and it is executed first.
+func ApplyMiddleware(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
 // RequestInjectHandler wraps all incoming requests and injects a logger in the request scoped context.
 func RequestInjectHandler(rootLogger *zap.Logger, traceIDHeaderName string, redactFn RedactFn, next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -72,3 +72,32 @@ func TestRequestInjectHandler(t *testing.T) {
 	// message
 	require.Equal(t, "injected", logEntry.Message)
 }
+
+func TestApplyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	ApplyMiddleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, []string{"handler"}, calls)
+
+	calls = nil
+
+	ApplyMiddleware(handler, mw("first"), mw("second")).ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, []string{"first", "second", "handler"}, calls)
+}
@@ -0,0 +1,206 @@
+// Package openapi generates an OpenAPI 3 document from the HTTP server routes.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+)
+
+// Version is the OpenAPI specification version of the generated documents.
+const Version = "3.0.3"
+
+const mimeJSON = "application/json"
+
+// regexPathParam matches the httprouter named (:name) and catch-all (*name) path parameters.
+var regexPathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// Document is the root object of the OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Info contains the API metadata.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem contains the operations of a path, mapped by lowercase HTTP method.
+type PathItem map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the request body.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType contains the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components contains the reusable schemas.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is the definition of a data type.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// New generates the OpenAPI document of the specified routes.
+// The request and response bodies are documented as JSON schemas generated from the Go types,
+// using the json tags for the property names and the validate "required" tag for the required properties.
+func New(info Info, routes []route.Route) *Document {
+	g := newSchemaGenerator()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	for _, r := range routes {
+		path := ConvertPath(r.Path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+
+		item[strings.ToLower(r.Method)] = newOperation(g, r)
+	}
+
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+
+	return doc
+}
+
+// ConvertPath converts the httprouter path parameters to the OpenAPI format (e.g. "/items/:id" to "/items/{id}").
+func ConvertPath(path string) string {
+	return regexPathParam.ReplaceAllString(path, "{$1}")
+}
+
+func newOperation(g *schemaGenerator, r route.Route) *Operation {
+	op := &Operation{
+		Description: r.Description,
+		Responses:   make(map[string]*Response, len(r.Responses)),
+	}
+
+	if r.Group != "" && r.Group != "/" {
+		op.Tags = []string{r.Group}
+	}
+
+	declared := make(map[string]bool, len(r.Parameters))
+
+	for _, p := range r.Parameters {
+		declared[p.In+":"+p.Name] = true
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == route.ParamInPath,
+			Schema:      g.valueSchema(p.Type),
+		})
+	}
+
+	for _, m := range regexPathParam.FindAllStringSubmatch(r.Path, -1) {
+		if declared[route.ParamInPath+":"+m[1]] {
+			continue
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     m[1],
+			In:       route.ParamInPath,
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	if r.RequestBody != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{mimeJSON: {Schema: g.valueSchema(r.RequestBody)}},
+		}
+	}
+
+	codes := make([]int, 0, len(r.Responses))
+	for code := range r.Responses {
+		codes = append(codes, code)
+	}
+
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		resp := r.Responses[code]
+
+		desc := resp.Description
+		if desc == "" {
+			desc = http.StatusText(code)
+		}
+
+		or := &Response{Description: desc}
+
+		if resp.Body != nil {
+			ct := resp.ContentType
+			if ct == "" {
+				ct = mimeJSON
+			}
+
+			or.Content = map[string]MediaType{ct: {Schema: g.valueSchema(resp.Body)}}
+		}
+
+		op.Responses[strconv.Itoa(code)] = or
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &Response{Description: "Default response"}
+	}
+
+	return op
+}
@@ -0,0 +1,174 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID      int64     `json:"id,string"`
+	Created time.Time `json:"created"`
+}
+
+type testItem struct {
+	testBase
+
+	Name     string            `json:"name" validate:"required,max=10"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Parent   *testItem         `json:"parent,omitempty"`
+	Score    *float64          `json:"score"`
+	Data     []byte            `json:"data"`
+	Any      interface{}       `json:"any"`
+	Ignored  string            `json:"-"`
+	NoTag    bool
+	internal string
+}
+
+type testError struct {
+	Message string `json:"message"`
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	info := Info{Title: "Test", Version: "1.2.3"}
+
+	routes := []route.Route{
+		{
+			Method:      http.MethodGet,
+			Path:        "/items/:id",
+			Description: "Get an item",
+			Group:       "/items",
+			Parameters: []route.Parameter{
+				{Name: "fields", In: route.ParamInQuery, Description: "fields to return"},
+				{Name: "limit", In: route.ParamInQuery, Required: true, Type: 0},
+			},
+			Responses: map[int]route.Response{
+				http.StatusOK:       {Body: testItem{}},
+				http.StatusNotFound: {Description: "Item not found", Body: &testError{}},
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/items/:id",
+			RequestBody: testItem{},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/files/*path",
+			Responses: map[int]route.Response{
+				http.StatusOK: {Body: "", ContentType: "text/plain"},
+			},
+		},
+	}
+
+	doc := New(info, routes)
+
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Equal(t, info, doc.Info)
+	require.Len(t, doc.Paths, 2)
+
+	get := doc.Paths["/items/{id}"]["get"]
+	require.NotNil(t, get)
+	require.Equal(t, "Get an item", get.Description)
+	require.Equal(t, []string{"/items"}, get.Tags)
+	require.Equal(t, []*Parameter{
+		{Name: "fields", In: route.ParamInQuery, Description: "fields to return", Schema: &Schema{Type: "string"}},
+		{Name: "limit", In: route.ParamInQuery, Required: true, Schema: &Schema{Type: "integer", Format: "int32"}},
+		{Name: "id", In: route.ParamInPath, Required: true, Schema: &Schema{Type: "string"}},
+	}, get.Parameters)
+	require.Equal(t, "OK", get.Responses["200"].Description)
+	require.Equal(t, "#/components/schemas/testItem", get.Responses["200"].Content["application/json"].Schema.Ref)
+	require.Equal(t, "Item not found", get.Responses["404"].Description)
+	require.Equal(t, "#/components/schemas/testError", get.Responses["404"].Content["application/json"].Schema.Ref)
+
+	post := doc.Paths["/items/{id}"]["post"]
+	require.NotNil(t, post)
+	require.Empty(t, post.Tags)
+	require.True(t, post.RequestBody.Required)
+	require.Equal(t, "#/components/schemas/testItem", post.RequestBody.Content["application/json"].Schema.Ref)
+	require.Equal(t, "Default response", post.Responses["default"].Description)
+
+	files := doc.Paths["/files/{path}"]["get"]
+	require.NotNil(t, files)
+	require.Len(t, files.Parameters, 1)
+	require.Equal(t, "path", files.Parameters[0].Name)
+	require.Equal(t, "string", files.Responses["200"].Content["text/plain"].Schema.Type)
+	require.NotContains(t, files.Responses["200"].Content, "application/json")
+
+	require.NotNil(t, doc.Components)
+	require.Len(t, doc.Components.Schemas, 2)
+
+	item := doc.Components.Schemas["testItem"]
+	require.Equal(t, "object", item.Type)
+	require.Equal(t, []string{"name"}, item.Required)
+	require.Equal(t, map[string]*Schema{
+		"id":      {Type: "string"},
+		"created": {Type: "string", Format: "date-time"},
+		"name":    {Type: "string"},
+		"tags":    {Type: "array", Items: &Schema{Type: "string"}},
+		"labels":  {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		"parent":  {Ref: "#/components/schemas/testItem"},
+		"score":   {Type: "number", Format: "double", Nullable: true},
+		"data":    {Type: "string", Format: "byte"},
+		"any":     {},
+		"NoTag":   {Type: "boolean"},
+	}, item.Properties)
+
+	_, err := json.Marshal(doc)
+	require.NoError(t, err)
+}
+
+func TestNew_empty(t *testing.T) {
+	t.Parallel()
+
+	doc := New(Info{Title: "Test", Version: "1.0.0"}, nil)
+
+	require.Empty(t, doc.Paths)
+	require.Nil(t, doc.Components)
+
+	b, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"openapi":"3.0.3","info":{"title":"Test","version":"1.0.0"},"paths":{}}`, string(b))
+}
+
+func TestConvertPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "no parameters",
+			path: "/items",
+			want: "/items",
+		},
+		{
+			name: "named parameters",
+			path: "/items/:id/tags/:tag",
+			want: "/items/{id}/tags/{tag}",
+		},
+		{
+			name: "catch-all parameter",
+			path: "/pprof/*option",
+			want: "/pprof/{option}",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, ConvertPath(tt.path))
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const componentsSchemasRef = "#/components/schemas/"
+
+var (
+	typeTime            = reflect.TypeOf(time.Time{})
+	typeDuration        = reflect.TypeOf(time.Duration(0))
+	typeJSONRawMessage  = reflect.TypeOf(json.RawMessage{})
+	typeJSONMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	regexInvalidNameChr = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// schemaGenerator generates the JSON schemas of Go types.
+// The named struct types are stored as reusable components and referenced, supporting recursive types.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// valueSchema returns the schema of the value type. A nil value is documented as string.
+func (g *schemaGenerator) valueSchema(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{Type: "string"}
+	}
+
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+//nolint:gocyclo
+func (g *schemaGenerator) typeSchema(t reflect.Type) *Schema {
+	switch t {
+	case typeTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typeDuration:
+		return &Schema{Type: "integer", Format: "int64"}
+	case typeJSONRawMessage:
+		return &Schema{}
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Ptr:
+		s := g.typeSchema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Implements(typeJSONMarshaler) || reflect.PtrTo(t).Implements(typeJSONMarshaler) {
+			return &Schema{}
+		}
+
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		return &Schema{Ref: componentsSchemasRef + g.componentName(t)}
+	}
+
+	// interfaces and other types can contain any value
+	return &Schema{}
+}
+
+// componentName returns the unique component name of a named struct type, generating the schema the first time.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := regexInvalidNameChr.ReplaceAllString(t.Name(), "_")
+
+	if _, ok := g.schemas[name]; ok {
+		pkg := t.PkgPath()
+		name = regexInvalidNameChr.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:], "_") + "." + name
+	}
+
+	// register the name before generating the schema to support recursive types
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	g.addStructFields(s, t)
+
+	return s
+}
+
+func (g *schemaGenerator) addStructFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				g.addStructFields(s, ft)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := g.typeSchema(f.Type)
+
+		if strings.Contains(","+opts+",", ",string,") {
+			fs = &Schema{Type: "string"}
+		}
+
+		s.Properties[name] = fs
+
+		if isRequired(f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// isRequired returns true if the validate tag contains the "required" rule.
+func isRequired(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+
+	return false
+}
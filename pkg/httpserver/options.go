@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/openapi"
+	"go.uber.org/zap"
 )
 
 // Option is a type alias for a function that configures the HTTP httpServer instance.
@@ -19,6 +23,9 @@ func WithRouter(r Router) Option {
 }
 
 // WithServerAddr sets the address the httpServer will bind to.
+// The address can be a TCP "host:port" (a zero port is assigned by the system),
+// a Unix domain socket "unix:///path/to/file.sock"
+// or a listener inherited via systemd socket activation "systemd://[name]".
 func WithServerAddr(addr string) Option {
 	return func(cfg *config) error {
 		cfg.serverAddr = addr
@@ -26,6 +33,15 @@ func WithServerAddr(addr string) Option {
 	}
 }
 
+// WithH2C enables the HTTP/2 cleartext (h2c) protocol on the non-TLS listeners,
+// for example to serve gRPC-web or a local sidecar proxy.
+func WithH2C() Option {
+	return func(cfg *config) error {
+		cfg.h2c = true
+		return nil
+	}
+}
+
 // WithServerReadHeaderTimeout sets the shutdown timeout.
 func WithServerReadHeaderTimeout(timeout time.Duration) Option {
 	return func(cfg *config) error {
@@ -42,7 +58,10 @@ func WithServerReadTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithServerWriteTimeout sets the shutdown timeout.
+// WithServerWriteTimeout sets the server write timeout.
+// The timeout is removed for the HTTP/1.x requests of the routes with a negative route.Route.Timeout,
+// so the long-lived responses of the streaming routes (e.g. Server-Sent Events) are not interrupted,
+// and it does not apply to the upgraded WebSocket connections.
 func WithServerWriteTimeout(timeout time.Duration) Option {
 	return func(cfg *config) error {
 		cfg.serverWriteTimeout = timeout
@@ -50,6 +69,35 @@ func WithServerWriteTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithRouteTimeout sets the default maximum duration of the requests for the routes without a specific timeout
+// (see route.Route.Timeout). The request context is canceled when the timeout expires
+// and the server responds with 503 Service Unavailable.
+// The timeout should be lower than the server WriteTimeout.
+func WithRouteTimeout(timeout time.Duration) Option {
+	return func(cfg *config) error {
+		if timeout < 0 {
+			return fmt.Errorf("the route timeout cannot be negative")
+		}
+
+		cfg.routeTimeout = timeout
+
+		return nil
+	}
+}
+
+// WithDeadlineHeaderName enables the inbound deadline propagation with the specified header name
+// (e.g. deadline.DefaultHeader).
+// The header contains the remaining time budget of the caller in milliseconds, used to shorten the request context.
+// The server responds with 504 Gateway Timeout when the budget expires before the route timeout.
+// The header is ignored by the routes with a disabled (negative) timeout, such as the streaming routes.
+// The httpclient.WithDeadlineHeaderName option forwards the remaining budget to the downstream services.
+func WithDeadlineHeaderName(name string) Option {
+	return func(cfg *config) error {
+		cfg.deadlineHeaderName = name
+		return nil
+	}
+}
+
 // WithShutdownTimeout sets the shutdown timeout.
 func WithShutdownTimeout(timeout time.Duration) Option {
 	return func(cfg *config) error {
@@ -75,6 +123,43 @@ func WithTLSCertData(pemCert, pemKey []byte) Option {
 	}
 }
 
+// WithTLSCertFiles enable TLS with the certificate and key loaded from the given PEM files.
+// The files are checked for changes at most once per reloadInterval during the TLS handshakes,
+// and the certificate is reloaded without restarting the server.
+// A zero reloadInterval sets DefaultTLSReloadInterval.
+func WithTLSCertFiles(certFile, keyFile string, reloadInterval time.Duration) Option {
+	return func(cfg *config) error {
+		r, err := newCertReloader(certFile, keyFile, reloadInterval)
+		if err != nil {
+			return fmt.Errorf("failed configuring TLS: %w", err)
+		}
+
+		cfg.tlsConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: r.GetCertificate,
+		}
+
+		return nil
+	}
+}
+
+// WithMutualTLS enables the mutual TLS authentication.
+// The client certificates are required and verified against the CA certificates in the given PEM bundle file.
+// The identity of the verified client is injected in the request context (see ClientIdentityFromContext) and logger.
+// This option requires a TLS certificate (see WithTLSCertData and WithTLSCertFiles).
+func WithMutualTLS(caFile string) Option {
+	return func(cfg *config) error {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return fmt.Errorf("failed configuring mutual TLS: %w", err)
+		}
+
+		cfg.clientCAs = pool
+
+		return nil
+	}
+}
+
 // WithInstrumentHandler set the http.Handler wrap function to collect metrics.
 func WithInstrumentHandler(handler InstrumentHandler) Option {
 	return func(cfg *config) error {
@@ -83,6 +168,104 @@ func WithInstrumentHandler(handler InstrumentHandler) Option {
 	}
 }
 
+// WithMiddleware adds global middlewares applied to all requests, including the ones not matching any route.
+// The middlewares are executed in order after the trace ID and logger injection and before the router.
+// This option can be used multiple times to append more middlewares.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(cfg *config) error {
+		cfg.middlewares = append(cfg.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// WithAccessLog enables the access log emitted when each request completes.
+// The access log middleware is executed before all the other global middlewares.
+func WithAccessLog(alCfg AccessLogConfig) Option {
+	return func(cfg *config) error {
+		if alCfg.SampleRate < 0 || alCfg.SampleRate > 1 {
+			return fmt.Errorf("the access log sample rate must be between 0 and 1")
+		}
+
+		cfg.accessLog = &alCfg
+
+		return nil
+	}
+}
+
+// WithCORS adds a global middleware to handle the Cross-Origin Resource Sharing (CORS) headers and preflight requests.
+func WithCORS(corsCfg CORSConfig) Option {
+	return func(cfg *config) error {
+		if len(corsCfg.AllowedOrigins) == 0 {
+			return fmt.Errorf("at least one CORS allowed origin is required")
+		}
+
+		cfg.middlewares = append(cfg.middlewares, CORSMiddleware(corsCfg))
+
+		return nil
+	}
+}
+
+// WithCompression adds a global middleware to compress the responses with gzip or brotli.
+func WithCompression(compCfg CompressionConfig) Option {
+	return func(cfg *config) error {
+		mw, err := CompressionMiddleware(compCfg)
+		if err != nil {
+			return err
+		}
+
+		cfg.middlewares = append(cfg.middlewares, mw)
+
+		return nil
+	}
+}
+
+// WithSecurityHeaders adds a global middleware to set the specified security headers on every response.
+// See DefaultSecurityHeaders and StrictSecurityHeaders for the available presets.
+func WithSecurityHeaders(headers SecurityHeaders) Option {
+	return func(cfg *config) error {
+		cfg.middlewares = append(cfg.middlewares, SecurityHeadersMiddleware(headers))
+		return nil
+	}
+}
+
+// WithRateLimit adds a global middleware to limit the rate of requests for each key (e.g. client IP or API key).
+// Use RateLimitMiddleware to limit single routes via route.Route.Middlewares.
+func WithRateLimit(rlCfg RateLimitConfig) Option {
+	return func(cfg *config) error {
+		mw, err := RateLimitMiddleware(rlCfg)
+		if err != nil {
+			return err
+		}
+
+		cfg.middlewares = append(cfg.middlewares, mw)
+
+		return nil
+	}
+}
+
+// WithIdempotency adds a global middleware to replay the responses of the unsafe requests with the same idempotency key.
+// Use IdempotencyMiddleware to enable the idempotency on single routes via route.Route.Middlewares.
+func WithIdempotency(idCfg IdempotencyConfig) Option {
+	return func(cfg *config) error {
+		cfg.middlewares = append(cfg.middlewares, IdempotencyMiddleware(idCfg))
+		return nil
+	}
+}
+
+// WithConcurrencyLimiter adds a global middleware to limit the number of in-flight requests.
+// Use the ConcurrencyLimiter.Middleware method to limit single routes via route.Route.Middlewares.
+func WithConcurrencyLimiter(cl *ConcurrencyLimiter) Option {
+	return func(cfg *config) error {
+		if cl == nil {
+			return fmt.Errorf("the concurrency limiter is required")
+		}
+
+		cfg.middlewares = append(cfg.middlewares, cl.Middleware())
+
+		return nil
+	}
+}
+
 // WithEnableDefaultRoutes sets the default routes to be enabled on the server.
 func WithEnableDefaultRoutes(ids ...defaultRoute) Option {
 	return func(cfg *config) error {
@@ -91,7 +274,8 @@ func WithEnableDefaultRoutes(ids ...defaultRoute) Option {
 	}
 }
 
-// WithEnableAllDefaultRoutes enables all default routes on the server.
+// WithEnableAllDefaultRoutes enables all default routes on the server,
+// except the OpenAPIRoute and the admin routes (see WithEnableAdminRoutes) that must be explicitly selected.
 func WithEnableAllDefaultRoutes() Option {
 	return func(cfg *config) error {
 		cfg.defaultEnabledRoutes = allDefaultRoutes()
@@ -99,6 +283,50 @@ func WithEnableAllDefaultRoutes() Option {
 	}
 }
 
+// WithEnableAdminRoutes enables all the admin routes on the server,
+// in addition to the routes enabled by WithEnableDefaultRoutes or WithEnableAllDefaultRoutes (in any order).
+// The admin routes must be protected with WithAdminAuthFunc, as required by the ConfigRoute.
+func WithEnableAdminRoutes() Option {
+	return func(cfg *config) error {
+		cfg.adminEnabledRoutes = allAdminRoutes()
+		return nil
+	}
+}
+
+// WithAdminAuthFunc sets the function used to authorize the requests to the admin routes.
+// The requests are rejected with 401 Unauthorized when the function returns an error.
+func WithAdminAuthFunc(fn AdminAuthFunc) Option {
+	return func(cfg *config) error {
+		if fn == nil {
+			return fmt.Errorf("the admin auth function is required")
+		}
+
+		cfg.adminAuthFunc = fn
+
+		return nil
+	}
+}
+
+// WithLogLevel sets the atomic logging level exposed by the LogLevelRoute.
+// The level is returned with GET and changed with PUT requests (e.g. {"level":"debug"}).
+// The same level should be passed to logging.WithAtomicLevel when creating the logger.
+func WithLogLevel(level zap.AtomicLevel) Option {
+	return func(cfg *config) error {
+		cfg.logLevelHandlerFunc = level.ServeHTTP
+		return nil
+	}
+}
+
+// WithConfigData sets the configuration returned as JSON by the ConfigRoute.
+// The JSON representation is obscured by the redact function set with WithRedactFn,
+// but the redaction is based on the field names, so the route also requires WithAdminAuthFunc.
+func WithConfigData(data interface{}) Option {
+	return func(cfg *config) error {
+		cfg.configData = data
+		return nil
+	}
+}
+
 // WithIndexHandlerFunc replaces the index handler.
 func WithIndexHandlerFunc(handler IndexHandlerFunc) Option {
 	return func(cfg *config) error {
@@ -107,6 +335,23 @@ func WithIndexHandlerFunc(handler IndexHandlerFunc) Option {
 	}
 }
 
+// WithOpenAPIInfo sets the API information of the OpenAPI document served by the OpenAPIRoute.
+func WithOpenAPIInfo(title, version, description string) Option {
+	return func(cfg *config) error {
+		if title == "" || version == "" {
+			return fmt.Errorf("the OpenAPI title and version are required")
+		}
+
+		cfg.openAPIInfo = openapi.Info{
+			Title:       title,
+			Version:     version,
+			Description: description,
+		}
+
+		return nil
+	}
+}
+
 // WithIPHandlerFunc replaces the default ip handler function.
 func WithIPHandlerFunc(handler http.HandlerFunc) Option {
 	return func(cfg *config) error {
@@ -147,6 +392,15 @@ func WithStatusHandlerFunc(handler http.HandlerFunc) Option {
 	}
 }
 
+// WithReadiness sets the readiness flag used by the status route to return 503 Service Unavailable
+// when the application is not ready to receive traffic (e.g. during warm-up or shutdown).
+func WithReadiness(r *healthcheck.Readiness) Option {
+	return func(cfg *config) error {
+		cfg.readiness = r
+		return nil
+	}
+}
+
 // WithTraceIDHeaderName overrides the default trace id header name.
 func WithTraceIDHeaderName(name string) Option {
 	return func(cfg *config) error {
@@ -2,13 +2,18 @@ package httpserver
 
 import (
 	"net/http"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/openapi"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestWithRouter(t *testing.T) {
@@ -61,6 +66,29 @@ func TestWithServerWriteTimeout(t *testing.T) {
 	require.Equal(t, v, cfg.serverWriteTimeout)
 }
 
+func TestWithRouteTimeout(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	v := 3 * time.Second
+	err := WithRouteTimeout(v)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, v, cfg.routeTimeout)
+
+	err = WithRouteTimeout(-1)(cfg)
+	require.Error(t, err)
+}
+
+func TestWithDeadlineHeaderName(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	v := "X-Test-Deadline"
+	err := WithDeadlineHeaderName(v)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, v, cfg.deadlineHeaderName)
+}
+
 func TestWithShutdownTimeout(t *testing.T) {
 	t.Parallel()
 
@@ -167,6 +195,52 @@ func TestWithEnableAllDefaultRoutes(t *testing.T) {
 	require.Equal(t, allDefaultRoutes(), cfg.defaultEnabledRoutes)
 }
 
+func TestWithEnableAdminRoutes(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithEnableAdminRoutes()(cfg)
+	require.NoError(t, err)
+	require.Equal(t, allAdminRoutes(), cfg.adminEnabledRoutes)
+
+	// the default routes can be set after the admin routes
+	err = WithEnableDefaultRoutes(PingRoute)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, append([]defaultRoute{PingRoute}, allAdminRoutes()...), cfg.enabledRoutes())
+}
+
+func TestWithAdminAuthFunc(t *testing.T) {
+	t.Parallel()
+
+	v := func(r *http.Request) error { return nil }
+	cfg := &config{}
+	err := WithAdminAuthFunc(v)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(cfg.adminAuthFunc).Pointer())
+
+	err = WithAdminAuthFunc(nil)(cfg)
+	require.Error(t, err)
+}
+
+func TestWithLogLevel(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithLogLevel(zap.NewAtomicLevel())(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.logLevelHandlerFunc)
+}
+
+func TestWithConfigData(t *testing.T) {
+	t.Parallel()
+
+	v := map[string]string{"key": "value"}
+	cfg := &config{}
+	err := WithConfigData(v)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, v, cfg.configData)
+}
+
 func TestWithIndexHandlerFunc(t *testing.T) {
 	t.Parallel()
 
@@ -181,6 +255,21 @@ func TestWithIndexHandlerFunc(t *testing.T) {
 	require.Equal(t, reflect.ValueOf(v).Pointer(), reflect.ValueOf(cfg.indexHandlerFunc).Pointer())
 }
 
+func TestWithOpenAPIInfo(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithOpenAPIInfo("Test API", "1.2.3", "Test description")(cfg)
+	require.NoError(t, err)
+	require.Equal(t, openapi.Info{Title: "Test API", Version: "1.2.3", Description: "Test description"}, cfg.openAPIInfo)
+
+	err = WithOpenAPIInfo("", "1.2.3", "")(cfg)
+	require.Error(t, err)
+
+	err = WithOpenAPIInfo("Test API", "", "")(cfg)
+	require.Error(t, err)
+}
+
 func TestWithIPHandlerFunc(t *testing.T) {
 	t.Parallel()
 
@@ -260,3 +349,165 @@ func TestWithRedactFn(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "alphatest", cfg.redactFn("alpha"))
 }
+
+func TestWithReadiness(t *testing.T) {
+	t.Parallel()
+
+	v := healthcheck.NewReadiness()
+	cfg := &config{}
+	err := WithReadiness(v)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, v, cfg.readiness)
+}
+
+func TestWithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	v := func(next http.Handler) http.Handler { return next }
+	cfg := &config{}
+	err := WithMiddleware(v)(cfg)
+	require.NoError(t, err)
+	err = WithMiddleware(v, v)(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 3)
+}
+
+func TestWithAccessLog(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	alCfg := AccessLogConfig{Level: zapcore.WarnLevel, SampleRate: 0.5, ExcludedPaths: []string{"/ping"}}
+	err := WithAccessLog(alCfg)(cfg)
+	require.NoError(t, err)
+	require.Equal(t, &alCfg, cfg.accessLog)
+
+	err = WithAccessLog(AccessLogConfig{SampleRate: -0.1})(cfg)
+	require.Error(t, err)
+
+	err = WithAccessLog(AccessLogConfig{SampleRate: 1.1})(cfg)
+	require.Error(t, err)
+}
+
+func TestWithCORS(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithCORS(CORSConfig{})(cfg)
+	require.Error(t, err)
+	require.Empty(t, cfg.middlewares)
+
+	err = WithCORS(CORSConfig{AllowedOrigins: []string{"*"}})(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithCompression(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithCompression(CompressionConfig{GzipLevel: intPtr(10)})(cfg)
+	require.Error(t, err)
+
+	err = WithCompression(CompressionConfig{BrotliLevel: intPtr(12)})(cfg)
+	require.Error(t, err)
+	require.Empty(t, cfg.middlewares)
+
+	err = WithCompression(CompressionConfig{GzipLevel: intPtr(9), BrotliLevel: intPtr(11)})(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithSecurityHeaders(DefaultSecurityHeaders())(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithRateLimit(RateLimitConfig{})(cfg)
+	require.Error(t, err)
+	require.Empty(t, cfg.middlewares)
+
+	err = WithRateLimit(RateLimitConfig{Limit: RateLimit{Requests: 10, Period: time.Second}})(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithIdempotency(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithIdempotency(IdempotencyConfig{})(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithConcurrencyLimiter(nil)(cfg)
+	require.Error(t, err)
+	require.Empty(t, cfg.middlewares)
+
+	cl, err := NewConcurrencyLimiter(ConcurrencyLimitConfig{Limit: 10})
+	require.NoError(t, err)
+
+	err = WithConcurrencyLimiter(cl)(cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.middlewares, 1)
+}
+
+func TestWithTLSCertFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	cfg := &config{}
+	err := WithTLSCertFiles(certFile, keyFile, 0)(cfg)
+	require.Error(t, err)
+	require.Nil(t, cfg.tlsConfig)
+
+	cert := newTestServerCert(t, newTestCA(t), "server")
+	writeTestFile(t, certFile, cert.certPEM)
+	writeTestFile(t, keyFile, cert.keyPEM)
+
+	err = WithTLSCertFiles(certFile, keyFile, 0)(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.tlsConfig)
+	require.NotNil(t, cfg.tlsConfig.GetCertificate)
+}
+
+func TestWithMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+
+	cfg := &config{}
+	err := WithMutualTLS(caFile)(cfg)
+	require.Error(t, err)
+	require.Nil(t, cfg.clientCAs)
+
+	writeTestFile(t, caFile, newTestCA(t).certPEM)
+
+	err = WithMutualTLS(caFile)(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.clientCAs)
+}
+
+func TestWithH2C(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{}
+	err := WithH2C()(cfg)
+	require.NoError(t, err)
+	require.True(t, cfg.h2c)
+}
@@ -0,0 +1,347 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultRateLimitName is the default rate limiter name used as key namespace and metrics operation.
+	DefaultRateLimitName = "default"
+
+	rateLimitMetricTask = "ratelimit"
+
+	// rateLimitSweepInterval is the minimum interval between the removal of the idle buckets from the memory store.
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimit defines a token bucket allowing up to Requests per Period, with bursts up to Requests.
+type RateLimit struct {
+	// Requests is the maximum number of requests (bucket size).
+	Requests int
+
+	// Period is the time needed to completely refill the bucket.
+	Period time.Duration
+}
+
+func (rl RateLimit) validate() error {
+	if rl.Requests <= 0 {
+		return fmt.Errorf("the rate limit requests must be positive: %d", rl.Requests)
+	}
+
+	if rl.Period <= 0 {
+		return fmt.Errorf("the rate limit period must be positive: %s", rl.Period)
+	}
+
+	return nil
+}
+
+// tokensPerSecond returns the bucket refill rate.
+func (rl RateLimit) tokensPerSecond() float64 {
+	return float64(rl.Requests) / rl.Period.Seconds()
+}
+
+// RateLimitResult contains the outcome of a rate limit check.
+type RateLimitResult struct {
+	// Allowed is true if the request can proceed.
+	Allowed bool
+
+	// Limit is the maximum number of requests in the period.
+	Limit int
+
+	// Remaining is the number of requests still available.
+	Remaining int
+
+	// Reset is the time needed to completely refill the bucket.
+	Reset time.Duration
+
+	// RetryAfter is the time to wait before the next request is allowed (only set when not allowed).
+	RetryAfter time.Duration
+}
+
+// RateLimitStore is the interface for the storage of the rate limit token buckets.
+// The default MemoryRateLimitStore can be replaced by a shared store to enforce the limits across multiple instances.
+type RateLimitStore interface {
+	// Take consumes one token from the bucket identified by the key.
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc is a type alias for the function returning the rate limit key of a request (e.g. the client IP).
+// An empty key excludes the request from the rate limiting.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitConfig contains the rate limiting settings.
+type RateLimitConfig struct {
+	// Name identifies the rate limiter. It is used to namespace the store keys and as metrics operation.
+	// The default value is DefaultRateLimitName.
+	Name string
+
+	// Limit is the token bucket definition applied to each key.
+	Limit RateLimit
+
+	// KeyFunc returns the key of the request.
+	// The default is the remote IP address (see RateLimitKeyByIP).
+	KeyFunc RateLimitKeyFunc
+
+	// Store is the token bucket storage.
+	// The default is a new MemoryRateLimitStore.
+	Store RateLimitStore
+
+	// Metrics is the optional client used to count the rejected requests and the store errors.
+	Metrics metrics.Client
+}
+
+// RateLimitMiddleware returns a middleware that limits the rate of requests for each key using a token bucket.
+// The rejected requests receive a 429 Too Many Requests response with the Retry-After header.
+// The RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers are set on every limited response.
+// In case of store errors the requests are allowed.
+func RateLimitMiddleware(cfg RateLimitConfig) (Middleware, error) {
+	if err := cfg.Limit.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = DefaultRateLimitName
+	}
+
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc, _ = RateLimitKeyByIP() // no error without trusted proxies
+	}
+
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore()
+	}
+
+	if cfg.Metrics == nil {
+		cfg.Metrics = &metrics.Default{}
+	}
+
+	policy := strconv.Itoa(cfg.Limit.Requests) + ";w=" + formatSeconds(cfg.Limit.Period)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := cfg.KeyFunc(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			res, err := cfg.Store.Take(ctx, cfg.Name+":"+key, cfg.Limit)
+			if err != nil {
+				logging.FromContext(ctx).Error("rate limit store error", zap.String("ratelimit", cfg.Name), zap.Error(err))
+				cfg.Metrics.IncErrorCounter(rateLimitMetricTask, cfg.Name, "store_error")
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", formatSeconds(res.Reset))
+			h.Set("RateLimit-Policy", policy)
+
+			if !res.Allowed {
+				cfg.Metrics.IncErrorCounter(rateLimitMetricTask, cfg.Name, "rejected")
+				h.Set("Retry-After", formatSeconds(res.RetryAfter))
+				httputil.SendStatus(ctx, w, http.StatusTooManyRequests)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// RateLimitKeyByHeader returns a key function that uses the value of the specified request header (e.g. an API key).
+// The requests without the header are not limited.
+func RateLimitKeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// RateLimitKeyByIP returns a key function that uses the client IP address.
+// The X-Forwarded-For header is only honoured when the request comes from one of the trusted proxies,
+// specified as IP addresses or CIDR networks (e.g. "10.0.0.0/8").
+func RateLimitKeyByIP(trustedProxies ...string) (RateLimitKeyFunc, error) {
+	nets := make([]*net.IPNet, 0, len(trustedProxies))
+
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					bits = 8 * net.IPv4len
+				}
+
+				p = fmt.Sprintf("%s/%d", p, bits)
+			}
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return func(r *http.Request) string {
+		return clientIP(r, nets)
+	}, nil
+}
+
+// clientIP returns the IP address of the client.
+// The X-Forwarded-For entries are inspected from right to left when the remote address is a trusted proxy,
+// and the first untrusted address is returned.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if len(trustedProxies) == 0 || !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	var fwd []string
+
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		fwd = append(fwd, strings.Split(v, ",")...)
+	}
+
+	ip := remote
+
+	for i := len(fwd) - 1; i >= 0; i-- {
+		v := strings.TrimSpace(fwd[i])
+		if net.ParseIP(v) == nil {
+			break
+		}
+
+		ip = v
+
+		if !isTrustedProxy(v, trustedProxies) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func isTrustedProxy(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatSeconds returns the duration as a string of seconds rounded up.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// MemoryRateLimitStore is an in-memory RateLimitStore for a single instance.
+// The idle buckets are periodically removed.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	nowFn     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+// NewMemoryRateLimitStore creates a new in-memory rate limit store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		nowFn:   time.Now,
+	}
+}
+
+// Take consumes one token from the bucket identified by the key.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	if err := limit.validate(); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFn()
+	s.sweep(now)
+
+	size := float64(limit.Requests)
+	rate := limit.tokensPerSecond()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: size, last: now}
+		s.buckets[key] = b
+	}
+
+	b.period = limit.Period
+	b.tokens = math.Min(size, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	res := RateLimitResult{Limit: limit.Requests}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = secondsToDuration((size - b.tokens) / rate)
+
+	return res, nil
+}
+
+// sweep removes the buckets that are already completely refilled.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+
+	s.lastSweep = now
+
+	for k, b := range s.buckets {
+		if now.Sub(b.last) >= b.period {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,283 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+type testErrorMetrics struct {
+	metrics.Default
+	mu    sync.Mutex
+	codes []string
+}
+
+func (m *testErrorMetrics) IncErrorCounter(task, operation, code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.codes = append(m.codes, task+":"+operation+":"+code)
+}
+
+type testRateLimitStore struct {
+	res RateLimitResult
+	err error
+}
+
+func (s *testRateLimitStore) Take(_ context.Context, _ string, _ RateLimit) (RateLimitResult, error) {
+	return s.res, s.err
+}
+
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryRateLimitStore()
+	s.nowFn = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := RateLimit{Requests: 2, Period: 10 * time.Second}
+
+	_, err := s.Take(ctx, "alpha", RateLimit{})
+	require.Error(t, err)
+
+	res, err := s.Take(ctx, "alpha", limit)
+	require.NoError(t, err)
+	require.Equal(t, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second}, res)
+
+	res, err = s.Take(ctx, "alpha", limit)
+	require.NoError(t, err)
+	require.Equal(t, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 10 * time.Second}, res)
+
+	res, err = s.Take(ctx, "alpha", limit)
+	require.NoError(t, err)
+	require.Equal(t, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 10 * time.Second, RetryAfter: 5 * time.Second}, res)
+
+	// other keys are not affected
+	res, err = s.Take(ctx, "beta", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	// one token is refilled
+	now = now.Add(5 * time.Second)
+
+	res, err = s.Take(ctx, "alpha", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Equal(t, 0, res.Remaining)
+
+	// the idle buckets are removed
+	now = now.Add(2 * rateLimitSweepInterval)
+
+	res, err = s.Take(ctx, "gamma", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+	require.Len(t, s.buckets, 1)
+}
+
+func TestRateLimitKeyByHeader(t *testing.T) {
+	t.Parallel()
+
+	fn := RateLimitKeyByHeader("X-Api-Key")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	require.Equal(t, "", fn(req))
+
+	req.Header.Set("X-Api-Key", "secret")
+	require.Equal(t, "secret", fn(req))
+}
+
+func TestRateLimitKeyByIP(t *testing.T) {
+	t.Parallel()
+
+	_, err := RateLimitKeyByIP("invalid")
+	require.Error(t, err)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		xff            []string
+		want           string
+	}{
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "192.0.2.1:1234",
+			xff:        []string{"203.0.113.1"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:           "untrusted remote address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "192.0.2.1:1234",
+			xff:            []string{"203.0.113.1"},
+			want:           "192.0.2.1",
+		},
+		{
+			name:           "trusted remote address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			xff:            []string{"198.51.100.1, 203.0.113.1"},
+			want:           "203.0.113.1",
+		},
+		{
+			name:           "trusted proxy chain",
+			trustedProxies: []string{"10.0.0.0/8", "192.0.2.7", "2001:db8::1"},
+			remoteAddr:     "[2001:db8::1]:1234",
+			xff:            []string{"198.51.100.1, 203.0.113.1", "192.0.2.7, 10.1.1.1"},
+			want:           "203.0.113.1",
+		},
+		{
+			name:           "all trusted",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			xff:            []string{"10.0.0.2, 10.0.0.3"},
+			want:           "10.0.0.2",
+		},
+		{
+			name:           "invalid forwarded address",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.1:1234",
+			xff:            []string{"unknown, 10.0.0.3"},
+			want:           "10.0.0.3",
+		},
+		{
+			name:           "remote address without port",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "192.0.2.1",
+			want:           "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fn, err := RateLimitKeyByIP(tt.trustedProxies...)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+
+			require.Equal(t, tt.want, fn(req))
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	_, err := RateLimitMiddleware(RateLimitConfig{})
+	require.Error(t, err)
+
+	_, err = RateLimitMiddleware(RateLimitConfig{Limit: RateLimit{Requests: 1}})
+	require.Error(t, err)
+
+	m := &testErrorMetrics{}
+
+	mw, err := RateLimitMiddleware(RateLimitConfig{
+		Name:    "api",
+		Limit:   RateLimit{Requests: 1, Period: time.Minute},
+		KeyFunc: RateLimitKeyByHeader("X-Api-Key"),
+		Metrics: m,
+	})
+	require.NoError(t, err)
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := serve("alpha")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+	require.Equal(t, "1;w=60", rr.Header().Get("RateLimit-Policy"))
+	require.Empty(t, rr.Header().Get("Retry-After"))
+
+	rr = serve("alpha")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.NotEmpty(t, rr.Header().Get("Retry-After"))
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	rr = serve("beta")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// requests without key are not limited
+	for i := 0; i < 3; i++ {
+		rr = serve("")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	}
+
+	require.Equal(t, []string{"ratelimit:api:rejected"}, m.codes)
+}
+
+func TestRateLimitMiddleware_defaults(t *testing.T) {
+	t.Parallel()
+
+	mw, err := RateLimitMiddleware(RateLimitConfig{Limit: RateLimit{Requests: 1, Period: time.Minute}})
+	require.NoError(t, err)
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		codes = append(codes, rr.Code)
+	}
+
+	require.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestRateLimitMiddleware_storeError(t *testing.T) {
+	t.Parallel()
+
+	m := &testErrorMetrics{}
+
+	mw, err := RateLimitMiddleware(RateLimitConfig{
+		Limit:   RateLimit{Requests: 1, Period: time.Minute},
+		Store:   &testRateLimitStore{err: fmt.Errorf("store error")},
+		Metrics: m,
+	})
+	require.NoError(t, err)
+
+	var called bool
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.True(t, called)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	require.Equal(t, []string{"ratelimit:default:store_error"}, m.codes)
+}
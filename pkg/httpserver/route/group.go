@@ -0,0 +1,214 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+)
+
+// Builder collects the routes of a group sharing the same path prefix, middlewares and description.
+type Builder struct {
+	prefix      string
+	description string
+	middlewares []Middleware
+	routes      []Route
+	groups      []*Builder
+}
+
+// Group returns a new route group builder.
+// The prefix is prepended to the path of all the routes in the group (e.g. "/v1"),
+// and the middlewares are executed in order before the middlewares of each route.
+func Group(prefix string, middlewares ...Middleware) *Builder {
+	return &Builder{
+		prefix:      "/" + strings.Trim(prefix, "/"),
+		middlewares: middlewares,
+	}
+}
+
+// Describe sets the description of the group, used for the routes without a description.
+func (b *Builder) Describe(description string) *Builder {
+	b.description = description
+	return b
+}
+
+// Add adds the routes to the group. The route paths are relative to the group prefix.
+func (b *Builder) Add(routes ...Route) *Builder {
+	b.routes = append(b.routes, routes...)
+	return b
+}
+
+// Group returns a new nested group inheriting the prefix, middlewares and description of the parent group.
+func (b *Builder) Group(prefix string, middlewares ...Middleware) *Builder {
+	g := Group(prefix, middlewares...)
+	b.groups = append(b.groups, g)
+
+	return g
+}
+
+// Routes returns the routes of the group and nested groups,
+// with the prefixed paths, the group middlewares and the group name set.
+func (b *Builder) Routes() []Route {
+	return b.build("", "", nil)
+}
+
+func (b *Builder) build(parentPrefix, parentDescription string, parentMiddlewares []Middleware) []Route {
+	prefix := joinPath(parentPrefix, b.prefix)
+
+	description := b.description
+	if description == "" {
+		description = parentDescription
+	}
+
+	middlewares := make([]Middleware, 0, len(parentMiddlewares)+len(b.middlewares))
+	middlewares = append(middlewares, parentMiddlewares...)
+	middlewares = append(middlewares, b.middlewares...)
+
+	routes := make([]Route, 0, len(b.routes))
+
+	for _, r := range b.routes {
+		r.Path = joinPath(prefix, r.Path)
+		r.Group = prefix
+
+		if r.Description == "" {
+			r.Description = description
+		}
+
+		rmw := make([]Middleware, 0, len(middlewares)+len(r.Middlewares))
+		rmw = append(rmw, middlewares...)
+		r.Middlewares = append(rmw, r.Middlewares...)
+
+		routes = append(routes, r)
+	}
+
+	for _, g := range b.groups {
+		routes = append(routes, g.build(prefix, description, middlewares)...)
+	}
+
+	return routes
+}
+
+// joinPath joins the prefix and the path with a single slash.
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	if path == "" || path == "/" {
+		if prefix == "" {
+			return "/"
+		}
+
+		return prefix
+	}
+
+	return prefix + "/" + strings.TrimPrefix(path, "/")
+}
+
+// HeaderVersions merges the routes of multiple API versions sharing the same method and path (header-based versioning).
+// Each request is dispatched to the handler and middlewares of the version selected by the specified header
+// (e.g. "API-Version"), or of the default version when the header is missing.
+// The selected version is returned in the same response header.
+// The requests for unknown versions are rejected with 400 Bad Request,
+// while the requests for known versions not serving the route are rejected with 404 Not Found.
+//
+// The merged route is a single route, so the versions of the same route must share the same
+// Timeout, Parameters, RequestBody and Responses, otherwise an error is returned.
+// The path-based versioning can be obtained with one Group for each version (e.g. "/v1" and "/v2").
+func HeaderVersions(header, defaultVersion string, versions map[string][]Route) ([]Route, error) {
+	names := make([]string, 0, len(versions))
+	for v := range versions {
+		names = append(names, v)
+	}
+
+	sort.Strings(names)
+
+	// process the default version first to use its descriptions
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == defaultVersion && names[j] != defaultVersion })
+
+	known := make(map[string]bool, len(names))
+	for _, v := range names {
+		known[v] = true
+	}
+
+	type versionedRoute struct {
+		route    Route
+		handlers map[string]http.Handler
+		versions []string
+	}
+
+	var keys []string
+
+	merged := make(map[string]*versionedRoute)
+
+	for _, v := range names {
+		for _, r := range versions[v] {
+			key := r.Method + " " + r.Path
+
+			vr, ok := merged[key]
+			if !ok {
+				vr = &versionedRoute{route: r, handlers: make(map[string]http.Handler)}
+				merged[key] = vr
+				keys = append(keys, key)
+			} else if !sameMetadata(vr.route, r) {
+				return nil, fmt.Errorf("the route %q of version %q has a different timeout, parameters, request body or responses than version %q", key, v, vr.versions[0])
+			}
+
+			var h http.Handler = r.Handler
+			for i := len(r.Middlewares) - 1; i >= 0; i-- {
+				h = r.Middlewares[i](h)
+			}
+
+			vr.handlers[v] = h
+			vr.versions = append(vr.versions, v)
+		}
+	}
+
+	routes := make([]Route, 0, len(keys))
+
+	for _, key := range keys {
+		vr := merged[key]
+
+		r := vr.route
+		r.Handler = versionHandler(header, defaultVersion, known, vr.handlers)
+		r.Middlewares = nil
+		r.Versions = vr.versions
+
+		routes = append(routes, r)
+	}
+
+	return routes, nil
+}
+
+// sameMetadata reports whether the two routes share the metadata applied to the merged route.
+func sameMetadata(a, b Route) bool {
+	return a.Timeout == b.Timeout &&
+		reflect.DeepEqual(a.Parameters, b.Parameters) &&
+		reflect.DeepEqual(a.RequestBody, b.RequestBody) &&
+		reflect.DeepEqual(a.Responses, b.Responses)
+}
+
+func versionHandler(header, defaultVersion string, known map[string]bool, handlers map[string]http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v := r.Header.Get(header)
+		if v == "" {
+			v = defaultVersion
+		}
+
+		h, ok := handlers[v]
+		if !ok {
+			status := http.StatusBadRequest
+			if known[v] {
+				status = http.StatusNotFound
+			}
+
+			httputil.SendStatus(r.Context(), w, status)
+
+			return
+		}
+
+		w.Header().Set(header, v)
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,208 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testHeaderMiddleware(value string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func testHandler(value string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Middleware", value)
+	}
+}
+
+func testServe(t *testing.T, handler http.Handler, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func testApply(r Route) http.Handler {
+	var h http.Handler = r.Handler
+	for i := len(r.Middlewares) - 1; i >= 0; i-- {
+		h = r.Middlewares[i](h)
+	}
+
+	return h
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	v1 := Group("/v1/", testHeaderMiddleware("v1")).Describe("Version 1")
+	v1.Add(
+		Route{Method: http.MethodGet, Path: "/items", Handler: testHandler("items"), Description: "List items"},
+		Route{Method: http.MethodPost, Path: "items/:id", Handler: testHandler("item"), Middlewares: []Middleware{testHeaderMiddleware("route")}},
+	)
+
+	admin := v1.Group("admin", testHeaderMiddleware("admin"))
+	admin.Add(Route{Method: http.MethodGet, Path: "/", Handler: testHandler("index")})
+
+	routes := v1.Routes()
+	require.Len(t, routes, 3)
+
+	require.Equal(t, "/v1/items", routes[0].Path)
+	require.Equal(t, "/v1", routes[0].Group)
+	require.Equal(t, "List items", routes[0].Description)
+	require.Equal(t, []string{"v1", "items"}, testServe(t, testApply(routes[0]), nil).Header().Values("X-Middleware"))
+
+	require.Equal(t, "/v1/items/:id", routes[1].Path)
+	require.Equal(t, "Version 1", routes[1].Description)
+	require.Equal(t, []string{"v1", "route", "item"}, testServe(t, testApply(routes[1]), nil).Header().Values("X-Middleware"))
+
+	require.Equal(t, "/v1/admin", routes[2].Path)
+	require.Equal(t, "/v1/admin", routes[2].Group)
+	require.Equal(t, "Version 1", routes[2].Description)
+	require.Equal(t, []string{"v1", "admin", "index"}, testServe(t, testApply(routes[2]), nil).Header().Values("X-Middleware"))
+
+	// the group routes are not modified
+	require.Equal(t, "/items", v1.routes[0].Path)
+	require.Len(t, v1.routes[1].Middlewares, 1)
+}
+
+func Test_joinPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefix string
+		path   string
+		want   string
+	}{
+		{prefix: "", path: "", want: "/"},
+		{prefix: "/", path: "/", want: "/"},
+		{prefix: "/", path: "/ping", want: "/ping"},
+		{prefix: "/v1", path: "", want: "/v1"},
+		{prefix: "/v1/", path: "items", want: "/v1/items"},
+		{prefix: "/v1", path: "/items/*path", want: "/v1/items/*path"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.prefix+"+"+tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, joinPath(tt.prefix, tt.path))
+		})
+	}
+}
+
+func TestHeaderVersions(t *testing.T) {
+	t.Parallel()
+
+	routes, err := HeaderVersions("API-Version", "1", map[string][]Route{
+		"2": {
+			{Method: http.MethodGet, Path: "/items", Handler: testHandler("items2"), Description: "List items v2"},
+			{Method: http.MethodGet, Path: "/tags", Handler: testHandler("tags2")},
+		},
+		"1": {
+			{
+				Method:      http.MethodGet,
+				Path:        "/items",
+				Handler:     testHandler("items1"),
+				Description: "List items",
+				Middlewares: []Middleware{testHeaderMiddleware("mw1")},
+			},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+
+	require.Equal(t, "/items", routes[0].Path)
+	require.Equal(t, "List items", routes[0].Description)
+	require.Equal(t, []string{"1", "2"}, routes[0].Versions)
+	require.Empty(t, routes[0].Middlewares)
+
+	require.Equal(t, "/tags", routes[1].Path)
+	require.Equal(t, []string{"2"}, routes[1].Versions)
+
+	// default version
+	rr := testServe(t, routes[0].Handler, nil)
+	require.Equal(t, []string{"mw1", "items1"}, rr.Header().Values("X-Middleware"))
+	require.Equal(t, "1", rr.Header().Get("API-Version"))
+
+	// selected version
+	rr = testServe(t, routes[0].Handler, http.Header{"Api-Version": []string{"2"}})
+	require.Equal(t, []string{"items2"}, rr.Header().Values("X-Middleware"))
+	require.Equal(t, "2", rr.Header().Get("API-Version"))
+
+	// known version without the route
+	rr = testServe(t, routes[1].Handler, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	require.Empty(t, rr.Header().Values("X-Middleware"))
+
+	// unknown version
+	rr = testServe(t, routes[1].Handler, http.Header{"Api-Version": []string{"3"}})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Empty(t, rr.Header().Values("X-Middleware"))
+}
+
+func TestHeaderVersions_differentMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		v2   Route
+	}{
+		{
+			name: "timeout",
+			v2:   Route{Method: http.MethodGet, Path: "/items", Handler: testHandler("items2"), Timeout: time.Second},
+		},
+		{
+			name: "parameters",
+			v2: Route{
+				Method:     http.MethodGet,
+				Path:       "/items",
+				Handler:    testHandler("items2"),
+				Parameters: []Parameter{{Name: "limit", In: ParamInQuery}},
+			},
+		},
+		{
+			name: "responses",
+			v2: Route{
+				Method:    http.MethodGet,
+				Path:      "/items",
+				Handler:   testHandler("items2"),
+				Responses: map[int]Response{http.StatusOK: {Description: "OK"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			routes, err := HeaderVersions("API-Version", "1", map[string][]Route{
+				"1": {{Method: http.MethodGet, Path: "/items", Handler: testHandler("items1")}},
+				"2": {tt.v2},
+			})
+
+			require.Error(t, err)
+			require.Nil(t, routes)
+		})
+	}
+}
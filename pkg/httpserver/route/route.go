@@ -3,8 +3,12 @@ package route
 
 import (
 	"net/http"
+	"time"
 )
 
+// Middleware is a function that wraps an http.Handler to add functionalities before and after the request is handled.
+type Middleware func(http.Handler) http.Handler
+
 // Route contains the HTTP route description.
 type Route struct {
 	// Method is the HTTP method (e.g.: GET, POST, PUT, DELETE, ...).
@@ -18,6 +22,73 @@ type Route struct {
 
 	// Description is the description of this route that is displayed by the /index endpoint.
 	Description string `json:"description"`
+
+	// Group is the path prefix of the route group, if any (see Group).
+	Group string `json:"group,omitempty"`
+
+	// Versions is the list of API versions served by this route, if any (see HeaderVersions).
+	Versions []string `json:"versions,omitempty"`
+
+	// Timeout is the maximum duration of the request, after which the request context is canceled
+	// and the server responds with 503 Service Unavailable.
+	// A zero value uses the default route timeout, if any, while a negative value disables it,
+	// together with the server WriteTimeout for the HTTP/1.x requests
+	// (e.g. for streaming routes, as the response of the routes with a timeout is buffered).
+	Timeout time.Duration `json:"-"`
+
+	// Middlewares is the list of middlewares applied only to this route, in order.
+	// The first middleware is the outermost one and it is executed after the instrumentation handler.
+	Middlewares []Middleware `json:"-"`
+
+	// Parameters is the optional list of path, query and header parameters used to generate the OpenAPI document.
+	// The path parameters not listed here are automatically documented as strings.
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// RequestBody is an optional value of the JSON request body type used to generate the OpenAPI document
+	// (e.g. MyRequest{}).
+	RequestBody interface{} `json:"-"`
+
+	// Responses optionally maps the HTTP status codes to the responses used to generate the OpenAPI document.
+	Responses map[int]Response `json:"-"`
+}
+
+// Parameter locations.
+const (
+	ParamInPath   = "path"
+	ParamInQuery  = "query"
+	ParamInHeader = "header"
+	ParamInCookie = "cookie"
+)
+
+// Parameter describes a route parameter.
+type Parameter struct {
+	// Name is the parameter name.
+	Name string `json:"name"`
+
+	// In is the parameter location: ParamInPath, ParamInQuery, ParamInHeader or ParamInCookie.
+	In string `json:"in"`
+
+	// Description is the parameter description.
+	Description string `json:"description,omitempty"`
+
+	// Required indicates if the parameter is mandatory. The path parameters are always required.
+	Required bool `json:"required,omitempty"`
+
+	// Type is an optional value of the parameter type (e.g. int64(0)). The default type is string.
+	Type interface{} `json:"-"`
+}
+
+// Response describes a route response.
+type Response struct {
+	// Description is the response description.
+	Description string
+
+	// Body is an optional value of the response body type (e.g. MyResponse{}).
+	Body interface{}
+
+	// ContentType is the media type of the response body.
+	// The default value is "application/json".
+	ContentType string
 }
 
 // Index contains the list of routes attached to the current service.
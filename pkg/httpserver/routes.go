@@ -3,7 +3,9 @@ package httpserver
 import (
 	"net/http"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
 )
 
 type defaultRoute string
@@ -21,6 +23,11 @@ const (
 	MetricsRoute       defaultRoute = "metrics"
 	metricsHandlerPath string       = "/metrics"
 
+	// OpenAPIRoute is the identifier to enable the OpenAPI document handler.
+	// This route is not enabled by WithEnableAllDefaultRoutes and must be explicitly selected.
+	OpenAPIRoute       defaultRoute = "openapi"
+	openAPIHandlerPath string       = "/openapi.json"
+
 	// PingRoute is the identifier to enable the ping handler.
 	PingRoute       defaultRoute = "ping"
 	pingHandlerPath string       = "/ping"
@@ -32,6 +39,25 @@ const (
 	// StatusRoute is the identifier to enable the status handler.
 	StatusRoute       defaultRoute = "status"
 	statusHandlerPath string       = "/status"
+
+	// BuildInfoRoute is the identifier to enable the admin build information handler.
+	BuildInfoRoute       defaultRoute = "buildinfo"
+	buildInfoHandlerPath string       = "/buildinfo"
+
+	// RuntimeRoute is the identifier to enable the admin runtime statistics, goroutine dump and GC trigger handlers.
+	RuntimeRoute          defaultRoute = "runtime"
+	runtimeHandlerPath    string       = "/runtime"
+	goroutinesHandlerPath string       = "/runtime/goroutines"
+	gcHandlerPath         string       = "/runtime/gc"
+
+	// LogLevelRoute is the identifier to enable the admin handler to get and set the logging level.
+	LogLevelRoute       defaultRoute = "loglevel"
+	logLevelHandlerPath string       = "/loglevel"
+
+	// ConfigRoute is the identifier to enable the admin handler returning the redacted configuration.
+	// This route requires an admin auth function (see WithAdminAuthFunc).
+	ConfigRoute       defaultRoute = "config"
+	configHandlerPath string       = "/config"
 )
 
 func allDefaultRoutes() []defaultRoute {
@@ -45,13 +71,27 @@ func allDefaultRoutes() []defaultRoute {
 	}
 }
 
+// allAdminRoutes returns the admin routes.
+// These routes are not enabled by WithEnableAllDefaultRoutes and must be explicitly selected.
+func allAdminRoutes() []defaultRoute {
+	return []defaultRoute{
+		BuildInfoRoute,
+		RuntimeRoute,
+		LogLevelRoute,
+		ConfigRoute,
+	}
+}
+
 func newDefaultRoutes(cfg *config) []route.Route {
-	routes := make([]route.Route, 0, len(cfg.defaultEnabledRoutes)+1)
+	ids := cfg.enabledRoutes()
+	routes := make([]route.Route, 0, len(ids)+1)
 
-	for _, id := range cfg.defaultEnabledRoutes {
+	for _, id := range ids {
 		switch id {
 		case IndexRoute:
 			// The index route needs to access all the routes bound to the handler.
+		case OpenAPIRoute:
+			// The OpenAPI route needs to access all the routes bound to the handler.
 		case IPRoute:
 			routes = append(routes, route.Route{
 				Method:      http.MethodGet,
@@ -84,11 +124,100 @@ func newDefaultRoutes(cfg *config) []route.Route {
 			routes = append(routes, route.Route{
 				Method:      http.MethodGet,
 				Path:        statusHandlerPath,
-				Handler:     cfg.statusHandlerFunc,
+				Handler:     readinessHandler(cfg.readiness, cfg.statusHandlerFunc),
 				Description: "Check this service health status.",
 			})
+		default:
+			routes = append(routes, newAdminRoutes(cfg, id)...)
+		}
+	}
+
+	return routes
+}
+
+// newAdminRoutes returns the admin routes for the specified identifier, protected by the admin auth function.
+func newAdminRoutes(cfg *config, id defaultRoute) []route.Route {
+	var routes []route.Route
+
+	switch id {
+	case BuildInfoRoute:
+		routes = []route.Route{
+			{
+				Method:      http.MethodGet,
+				Path:        buildInfoHandlerPath,
+				Handler:     buildInfoHandler,
+				Description: "Returns the build information of this service binary.",
+			},
+		}
+	case RuntimeRoute:
+		routes = []route.Route{
+			{
+				Method:      http.MethodGet,
+				Path:        runtimeHandlerPath,
+				Handler:     runtimeHandler,
+				Description: "Returns the Go runtime statistics.",
+			},
+			{
+				Method:      http.MethodGet,
+				Path:        goroutinesHandlerPath,
+				Handler:     goroutinesHandler,
+				Description: "Returns the stack traces of all the goroutines.",
+			},
+			{
+				Method:      http.MethodPost,
+				Path:        gcHandlerPath,
+				Handler:     gcHandler,
+				Description: "Forces a garbage collection and returns the Go runtime statistics.",
+			},
+		}
+	case LogLevelRoute:
+		routes = []route.Route{
+			{
+				Method:      http.MethodGet,
+				Path:        logLevelHandlerPath,
+				Handler:     cfg.logLevelHandlerFunc,
+				Description: "Returns the current logging level.",
+			},
+			{
+				Method:      http.MethodPut,
+				Path:        logLevelHandlerPath,
+				Handler:     cfg.logLevelHandlerFunc,
+				Description: "Sets the logging level.",
+			},
+		}
+	case ConfigRoute:
+		routes = []route.Route{
+			{
+				Method:      http.MethodGet,
+				Path:        configHandlerPath,
+				Handler:     configHandler(cfg.configData, cfg.redactFn),
+				Description: "Returns the redacted service configuration.",
+			},
+		}
+	}
+
+	if cfg.adminAuthFunc != nil {
+		for i := range routes {
+			routes[i].Middlewares = []Middleware{adminAuthMiddleware(cfg.adminAuthFunc)}
 		}
 	}
 
 	return routes
 }
+
+// readinessHandler returns 503 Service Unavailable when the application is not ready,
+// otherwise it calls the next handler.
+func readinessHandler(r *healthcheck.Readiness, next http.HandlerFunc) http.HandlerFunc {
+	if r == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !r.IsReady() {
+			httputil.SendStatus(req.Context(), w, http.StatusServiceUnavailable)
+			return
+		}
+
+		next(w, req)
+	}
+}
@@ -2,9 +2,12 @@ package httpserver
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/nexmoinc/gosrvlib/pkg/healthcheck"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,3 +44,60 @@ func Test_newDefaultRoutes(t *testing.T) {
 
 	require.Equal(t, 5, boundCount)
 }
+
+func Test_newDefaultRoutes_admin(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config{
+		defaultEnabledRoutes: []defaultRoute{ConfigRoute, PingRoute},
+		adminEnabledRoutes:   allAdminRoutes(),
+		pingHandlerFunc:      defaultPingHandler,
+		logLevelHandlerFunc:  notImplementedHandler,
+	}
+
+	routes := newDefaultRoutes(cfg)
+	require.Len(t, routes, 8)
+
+	for _, r := range routes {
+		require.Empty(t, r.Middlewares)
+	}
+
+	cfg.adminAuthFunc = func(r *http.Request) error { return nil }
+
+	routes = newDefaultRoutes(cfg)
+	require.Len(t, routes, 8)
+
+	for _, r := range routes {
+		if r.Path == pingHandlerPath {
+			require.Empty(t, r.Middlewares)
+			continue
+		}
+
+		require.Len(t, r.Middlewares, 1)
+	}
+}
+
+func Test_readinessHandler(t *testing.T) {
+	t.Parallel()
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	require.Equal(t, reflect.ValueOf(next).Pointer(), reflect.ValueOf(readinessHandler(nil, next)).Pointer())
+
+	readiness := healthcheck.NewReadiness()
+	handler := readinessHandler(readiness, next)
+
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	readiness.SetReady(true)
+
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		headers SecurityHeaders
+	}{
+		{
+			name:    "default preset",
+			headers: DefaultSecurityHeaders(),
+		},
+		{
+			name:    "strict preset",
+			headers: StrictSecurityHeaders(),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			rr := httptest.NewRecorder()
+			SecurityHeadersMiddleware(tt.headers)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			for k, v := range tt.headers {
+				require.Equal(t, v, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersMiddleware_override(t *testing.T) {
+	t.Parallel()
+
+	headers := DefaultSecurityHeaders()
+	headers["X-Frame-Options"] = ""
+	headers["Content-Security-Policy"] = "default-src 'self'"
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Referrer-Policy", "origin")
+	})
+
+	rr := httptest.NewRecorder()
+	SecurityHeadersMiddleware(headers)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Empty(t, rr.Header().Get("X-Frame-Options"))
+	require.Equal(t, "default-src 'self'", rr.Header().Get("Content-Security-Policy"))
+	require.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	require.Equal(t, "origin", rr.Header().Get("Referrer-Policy"))
+}
+
+func TestSecurityHeadersMiddleware_isolatedValues(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/change" {
+			w.Header()["X-Content-Type-Options"][0] = "changed"
+		}
+	})
+
+	handler := SecurityHeadersMiddleware(DefaultSecurityHeaders())(next)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/change", nil))
+	require.Equal(t, "changed", rr.Header().Get("X-Content-Type-Options"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+}
@@ -0,0 +1,193 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/deadline"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// timeoutHandler runs the handler with a request context canceled after the route timeout
+// or the time budget received with the deadline header, whichever comes first.
+//
+// When the route timeout expires the response is 503 Service Unavailable,
+// while when the caller budget expires the response is 504 Gateway Timeout.
+// Requests received with an already expired budget are rejected with 504 without calling the handler.
+// The handler is called directly when no timeout applies,
+// otherwise the response is buffered and it is discarded on timeout (see http.TimeoutHandler).
+// The deadline header is ignored when the route timeout is negative (disabled),
+// as required by the streaming routes (e.g. Server-Sent Events and WebSocket).
+func timeoutHandler(timeout time.Duration, deadlineHeaderName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusServiceUnavailable
+
+		if deadlineHeaderName != "" && timeout >= 0 {
+			if budget, ok := deadline.FromHTTPRequestHeader(r, deadlineHeaderName); ok {
+				if budget <= 0 {
+					logging.FromContext(r.Context()).Warn("request deadline already expired")
+					httputil.SendStatus(r.Context(), w, http.StatusGatewayTimeout)
+
+					return
+				}
+
+				if timeout <= 0 || budget < timeout {
+					timeout = budget
+					status = http.StatusGatewayTimeout
+				}
+			}
+		}
+
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		serveWithTimeout(w, r, timeout, status, next)
+	})
+}
+
+// connCtxKey is the context key of the network connection of the request (see http.Server.ConnContext).
+type connCtxKey struct{}
+
+// withConn returns a copy of the context containing the network connection.
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connCtxKey{}, c)
+}
+
+// streamingHandler removes the server WriteTimeout from the connection of the streaming routes
+// (i.e. the routes with a negative timeout), as the long-lived responses would be interrupted when it expires.
+// The server sets the write deadline again for the next request on the same connection.
+// Only the HTTP/1.x requests are supported, as the HTTP/2 connections are shared by multiple requests.
+func streamingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := r.Context().Value(connCtxKey{}).(net.Conn); ok && r.ProtoMajor == 1 {
+			if err := c.SetWriteDeadline(time.Time{}); err != nil {
+				logging.FromContext(r.Context()).Warn("failed removing the write deadline", zap.Error(err))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func serveWithTimeout(w http.ResponseWriter, r *http.Request, timeout time.Duration, status int, next http.Handler) {
+	reqCtx := r.Context()
+
+	ctx, cancel := context.WithTimeout(reqCtx, timeout)
+	defer cancel()
+
+	r = r.WithContext(ctx)
+
+	tw := &timeoutWriter{
+		header: make(http.Header),
+	}
+
+	done := make(chan struct{})
+	panicChan := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicChan <- p
+			}
+		}()
+
+		next.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicChan:
+		panic(p)
+	case <-done:
+		tw.flushTo(w)
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+
+		tw.timedOut = true
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) || reqCtx.Err() != nil {
+			// the client disconnected or the server is shutting down
+			return
+		}
+
+		logging.FromContext(reqCtx).Warn("request timeout", zap.Duration("request_timeout", timeout))
+		httputil.SendStatus(reqCtx, w, status)
+	}
+}
+
+// timeoutWriter buffers the response until the handler returns.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header returns the buffered header map.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers the data, returning http.ErrHandlerTimeout after the timeout.
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+
+	return tw.buf.Write(p) //nolint:wrapcheck
+}
+
+// WriteHeader buffers the status code.
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// flushTo writes the buffered response.
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := w.Header()
+	for k, vv := range tw.header {
+		dst[k] = vv
+	}
+
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+	}
+
+	w.WriteHeader(tw.code)
+
+	_, _ = w.Write(tw.buf.Bytes())
+}
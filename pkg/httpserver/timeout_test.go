@@ -0,0 +1,336 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/deadline"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_timeoutHandler(t *testing.T) {
+	t.Parallel()
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+
+		w.Header().Set("X-Test", "slow")
+		_, _ = w.Write([]byte("late"))
+	})
+
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		w.Header().Set("X-Test", "fast")
+		w.WriteHeader(http.StatusCreated)
+
+		if ok {
+			_, _ = w.Write([]byte("deadline"))
+		}
+	})
+
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		header     string
+		budget     string
+		handler    http.Handler
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name:       "no timeout",
+			handler:    fast,
+			wantStatus: http.StatusCreated,
+			wantHeader: "fast",
+		},
+		{
+			name:       "within timeout",
+			timeout:    time.Second,
+			handler:    fast,
+			wantStatus: http.StatusCreated,
+			wantBody:   "deadline",
+			wantHeader: "fast",
+		},
+		{
+			name:       "route timeout",
+			timeout:    10 * time.Millisecond,
+			handler:    slow,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "budget ignored without header name",
+			timeout:    10 * time.Millisecond,
+			budget:     "1",
+			handler:    slow,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "budget lower than route timeout",
+			timeout:    time.Second,
+			header:     deadline.DefaultHeader,
+			budget:     "10",
+			handler:    slow,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "budget without route timeout",
+			header:     deadline.DefaultHeader,
+			budget:     "10",
+			handler:    slow,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "route timeout lower than budget",
+			timeout:    10 * time.Millisecond,
+			header:     deadline.DefaultHeader,
+			budget:     "5000",
+			handler:    slow,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "budget ignored with disabled route timeout",
+			timeout:    -1,
+			header:     deadline.DefaultHeader,
+			budget:     "10",
+			handler:    fast,
+			wantStatus: http.StatusCreated,
+			wantHeader: "fast",
+		},
+		{
+			name:       "expired budget",
+			header:     deadline.DefaultHeader,
+			budget:     "0",
+			handler:    fast,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "invalid budget",
+			header:     deadline.DefaultHeader,
+			budget:     "invalid",
+			handler:    fast,
+			wantStatus: http.StatusCreated,
+			wantHeader: "fast",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+
+			if tt.budget != "" {
+				req.Header.Set(deadline.DefaultHeader, tt.budget)
+			}
+
+			timeoutHandler(tt.timeout, tt.header, tt.handler).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			require.Equal(t, tt.wantHeader, rr.Header().Get("X-Test"))
+
+			if tt.wantBody != "" {
+				require.Equal(t, tt.wantBody, rr.Body.String())
+			}
+
+			require.NotContains(t, rr.Body.String(), "late")
+
+			if tt.timeout < 0 {
+				require.Empty(t, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_timeoutHandler_canceled(t *testing.T) {
+	t.Parallel()
+
+	handler := timeoutHandler(time.Second, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("canceled"))
+	}))
+
+	ctx, cancel := context.WithCancel(testutil.Context())
+	cancel()
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	handler.ServeHTTP(rr, req)
+
+	// nothing is written for the disconnected clients
+	require.False(t, rr.Flushed)
+	require.Empty(t, rr.Body.String())
+}
+
+func Test_timeoutHandler_panic(t *testing.T) {
+	t.Parallel()
+
+	handler := timeoutHandler(time.Second, "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("test panic")
+	}))
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+
+	require.PanicsWithValue(t, "test panic", func() { handler.ServeHTTP(rr, req) })
+}
+
+type testTimeoutBinder struct{}
+
+func (b *testTimeoutBinder) BindHTTP(_ context.Context) []route.Route {
+	wait := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+
+	return []route.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/default",
+			Handler: wait,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/report",
+			Handler: wait,
+			Timeout: 200 * time.Millisecond,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/events",
+			Handler: httputil.SSEHandler(httputil.SSEConfig{}, func(ctx context.Context, stream *httputil.SSEStream) error {
+				if err := stream.Send(httputil.SSEEvent{Event: "first", Data: "1"}); err != nil {
+					return err
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(100 * time.Millisecond):
+				}
+
+				return stream.Send(httputil.SSEEvent{Event: "second", Data: "2"})
+			}),
+			Timeout: -1,
+		},
+	}
+}
+
+func TestStart_routeTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, &testTimeoutBinder{},
+		WithServerAddr("127.0.0.1:0"),
+		WithRouteTimeout(10*time.Millisecond),
+		WithDeadlineHeaderName(deadline.DefaultHeader),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	do := func(path, budget string) (int, time.Duration) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+path, nil)
+		require.NoError(t, err)
+
+		if budget != "" {
+			req.Header.Set(deadline.DefaultHeader, budget)
+		}
+
+		start := time.Now()
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		return resp.StatusCode, time.Since(start)
+	}
+
+	code, _ := do("/default", "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, elapsed := do("/report", "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+
+	code, elapsed = do("/report", "20")
+	require.Equal(t, http.StatusGatewayTimeout, code)
+	require.Less(t, elapsed, 200*time.Millisecond)
+}
+
+func TestStart_streamingRouteDeadline(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, &testTimeoutBinder{},
+		WithServerAddr("127.0.0.1:0"),
+		WithRouteTimeout(10*time.Millisecond),
+		WithDeadlineHeaderName(deadline.DefaultHeader),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+"/events", nil)
+	require.NoError(t, err)
+
+	// the budget is lower than the stream duration
+	req.Header.Set(deadline.DefaultHeader, "20")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, httputil.MimeTextEventStream, resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "event: first\n")
+	require.Contains(t, string(body), "event: second\n")
+}
+
+func TestStart_streamingRouteWriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, &testTimeoutBinder{},
+		WithServerAddr("127.0.0.1:0"),
+		WithServerWriteTimeout(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+s.Addr().String()+"/events", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the stream lasts longer than the server write timeout
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "event: first\n")
+	require.Contains(t, string(body), "event: second\n")
+}
@@ -0,0 +1,231 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// DefaultTLSReloadInterval is the default minimum interval between the checks for changed certificate files.
+const DefaultTLSReloadInterval = time.Minute
+
+// certReloader provides the TLS certificate loaded from files,
+// reloading it when the files are modified.
+type certReloader struct {
+	certFile  string
+	keyFile   string
+	interval  time.Duration
+	nowFn     func() time.Time
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	if interval <= 0 {
+		interval = DefaultTLSReloadInterval
+	}
+
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+		nowFn:    time.Now,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	r.lastCheck = r.nowFn()
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, checking the files for changes at most once per interval.
+// In case of reload errors the previous certificate is kept.
+func (r *certReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.nowFn()
+
+	if now.Sub(r.lastCheck) >= r.interval {
+		r.lastCheck = now
+
+		if r.isChanged() {
+			if err := r.load(); err != nil {
+				r.logError(hello, err)
+			}
+		}
+	}
+
+	return r.cert, nil
+}
+
+func (r *certReloader) logError(hello *tls.ClientHelloInfo, err error) {
+	ctx := hello.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	logging.FromContext(ctx).Error("failed reloading the TLS certificate", zap.Error(err))
+}
+
+func (r *certReloader) isChanged() bool {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return false
+	}
+
+	return !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod)
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed reading the TLS certificate file: %w", err)
+	}
+
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed reading the TLS key file: %w", err)
+	}
+
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (r *certReloader) load() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed loading the TLS certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.certMod = certMod
+	r.keyMod = keyMod
+
+	return nil
+}
+
+// loadCertPool returns a certificate pool with the PEM certificates in the specified file.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the CA bundle file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no valid certificates found in the CA bundle file: %s", caFile)
+	}
+
+	return pool, nil
+}
+
+type clientIdentityCtxKey struct{}
+
+// ClientIdentity contains the identity of a client authenticated with a verified TLS certificate.
+type ClientIdentity struct {
+	// Subject is the certificate subject distinguished name.
+	Subject string `json:"subject"`
+
+	// CommonName is the certificate subject common name.
+	CommonName string `json:"common_name"`
+
+	// DNSNames contains the DNS Subject Alternative Names.
+	DNSNames []string `json:"dns_names,omitempty"`
+
+	// EmailAddresses contains the email Subject Alternative Names.
+	EmailAddresses []string `json:"email_addresses,omitempty"`
+
+	// URIs contains the URI Subject Alternative Names (e.g. SPIFFE IDs).
+	URIs []string `json:"uris,omitempty"`
+
+	// IPAddresses contains the IP Subject Alternative Names.
+	IPAddresses []string `json:"ip_addresses,omitempty"`
+
+	// SerialNumber is the certificate serial number.
+	SerialNumber string `json:"serial_number"`
+}
+
+// newClientIdentity extracts the client identity from the certificate.
+func newClientIdentity(cert *x509.Certificate) *ClientIdentity {
+	id := &ClientIdentity{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		SerialNumber:   cert.SerialNumber.String(),
+	}
+
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+
+	for _, ip := range cert.IPAddresses {
+		id.IPAddresses = append(id.IPAddresses, ip.String())
+	}
+
+	return id
+}
+
+// SANs returns all the Subject Alternative Names.
+func (id *ClientIdentity) SANs() []string {
+	sans := make([]string, 0, len(id.DNSNames)+len(id.EmailAddresses)+len(id.URIs)+len(id.IPAddresses))
+	sans = append(sans, id.DNSNames...)
+	sans = append(sans, id.EmailAddresses...)
+	sans = append(sans, id.URIs...)
+	sans = append(sans, id.IPAddresses...)
+
+	return sans
+}
+
+// WithClientIdentity returns a new context with the TLS client identity.
+func WithClientIdentity(ctx context.Context, id *ClientIdentity) context.Context {
+	return context.WithValue(ctx, clientIdentityCtxKey{}, id)
+}
+
+// ClientIdentityFromContext returns the TLS client identity stored in the context, if any.
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityCtxKey{}).(*ClientIdentity)
+	return id, ok
+}
+
+// clientIdentityMiddleware injects the identity of the verified client certificate in the request context and logger.
+func clientIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		id := newClientIdentity(r.TLS.VerifiedChains[0][0])
+
+		l := logging.FromContext(ctx).With(
+			zap.String("client_subject", id.Subject),
+			zap.Strings("client_sans", id.SANs()),
+		)
+
+		ctx = logging.WithLogger(ctx, l)
+		ctx = WithClientIdentity(ctx, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,346 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert generates a certificate signed by the parent, or self-signed if the parent is nil.
+func newTestCert(t *testing.T, tmpl *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func newTestCA(t *testing.T) *testCert {
+	t.Helper()
+
+	return newTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+func newTestServerCert(t *testing.T, ca *testCert, cn string) *testCert {
+	t.Helper()
+
+	return newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestCertReloader(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	_, err := newCertReloader(certFile, keyFile, 0)
+	require.Error(t, err)
+
+	ca := newTestCA(t)
+	first := newTestServerCert(t, ca, "first")
+
+	writeTestFile(t, certFile, first.certPEM)
+	writeTestFile(t, keyFile, []byte("invalid"))
+
+	_, err = newCertReloader(certFile, keyFile, 0)
+	require.Error(t, err)
+
+	writeTestFile(t, keyFile, first.keyPEM)
+
+	r, err := newCertReloader(certFile, keyFile, 0)
+	require.NoError(t, err)
+	require.Equal(t, DefaultTLSReloadInterval, r.interval)
+
+	now := time.Now()
+	r.nowFn = func() time.Time { return now }
+
+	hello := &tls.ClientHelloInfo{}
+
+	getCN := func() string {
+		c, err := r.GetCertificate(hello)
+		require.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(c.Certificate[0])
+		require.NoError(t, err)
+
+		return leaf.Subject.CommonName
+	}
+
+	require.Equal(t, "first", getCN())
+
+	// replace the certificate files
+	second := newTestServerCert(t, ca, "second")
+	writeTestFile(t, certFile, second.certPEM)
+	writeTestFile(t, keyFile, second.keyPEM)
+
+	modTime := now.Add(time.Hour)
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	// not reloaded before the interval
+	require.Equal(t, "first", getCN())
+
+	now = now.Add(DefaultTLSReloadInterval)
+	require.Equal(t, "second", getCN())
+
+	// invalid files keep the previous certificate
+	writeTestFile(t, keyFile, []byte("invalid"))
+
+	modTime = modTime.Add(time.Hour)
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	now = now.Add(DefaultTLSReloadInterval)
+	require.Equal(t, "second", getCN())
+
+	// missing files keep the previous certificate
+	require.NoError(t, os.Remove(keyFile))
+
+	now = now.Add(DefaultTLSReloadInterval)
+	require.Equal(t, "second", getCN())
+}
+
+func Test_loadCertPool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+
+	_, err := loadCertPool(caFile)
+	require.Error(t, err)
+
+	writeTestFile(t, caFile, []byte("invalid"))
+
+	_, err = loadCertPool(caFile)
+	require.Error(t, err)
+
+	writeTestFile(t, caFile, newTestCA(t).certPEM)
+
+	pool, err := loadCertPool(caFile)
+	require.NoError(t, err)
+	require.NotNil(t, pool)
+}
+
+func TestClientIdentity(t *testing.T) {
+	t.Parallel()
+
+	u, err := url.Parse("spiffe://example.org/service")
+	require.NoError(t, err)
+
+	ca := newTestCA(t)
+	client := newTestCert(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "client", Organization: []string{"Example"}},
+		DNSNames:       []string{"client.example.org"},
+		EmailAddresses: []string{"client@example.org"},
+		URIs:           []*url.URL{u},
+		IPAddresses:    []net.IP{net.ParseIP("192.0.2.1")},
+	}, ca)
+
+	id := newClientIdentity(client.cert)
+	require.Equal(t, "CN=client,O=Example", id.Subject)
+	require.Equal(t, "client", id.CommonName)
+	require.Equal(t, client.cert.SerialNumber.String(), id.SerialNumber)
+	require.Equal(t, []string{"client.example.org", "client@example.org", "spiffe://example.org/service", "192.0.2.1"}, id.SANs())
+
+	ctx := context.Background()
+
+	_, ok := ClientIdentityFromContext(ctx)
+	require.False(t, ok)
+
+	got, ok := ClientIdentityFromContext(WithClientIdentity(ctx, id))
+	require.True(t, ok)
+	require.Equal(t, id, got)
+}
+
+func Test_clientIdentityMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	client := newTestCert(t, &x509.Certificate{Subject: pkix.Name{CommonName: "client"}, DNSNames: []string{"client.example.org"}}, ca)
+
+	ctx, logs := testutil.ContextWithLogObserver(zapcore.DebugLevel)
+
+	var id *ClientIdentity
+
+	h := clientIdentityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ = ClientIdentityFromContext(r.Context())
+		logging.FromContext(r.Context()).Info("test")
+	}))
+
+	// no TLS
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.Nil(t, id)
+
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{client.cert, ca.cert}}}
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotNil(t, id)
+	require.Equal(t, "client", id.CommonName)
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	require.Equal(t, "CN=client", entries[1].ContextMap()["client_subject"])
+}
+
+func TestNewServer_mutualTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	server := newTestServerCert(t, ca, "server")
+	client := newTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		DNSNames:    []string{"client.example.org"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	writeTestFile(t, caFile, ca.certPEM)
+	writeTestFile(t, certFile, server.certPEM)
+	writeTestFile(t, keyFile, server.keyPEM)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBinder := NewMockBinder(mockCtrl)
+	mockBinder.EXPECT().BindHTTP(gomock.Any()).Return([]route.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/identity",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				id, _ := ClientIdentityFromContext(r.Context())
+				_ = json.NewEncoder(w).Encode(id)
+			},
+		},
+	})
+
+	ctx := testutil.Context()
+
+	s, err := NewServer(ctx, mockBinder,
+		WithServerAddr("127.0.0.1:0"),
+		WithTLSCertFiles(certFile, keyFile, time.Second),
+		WithMutualTLS(caFile),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	clientCert, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	require.NoError(t, err)
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion:   tls.VersionTLS12,
+					RootCAs:      pool,
+					Certificates: certs,
+				},
+			},
+		}
+	}
+
+	reqURL := "https://" + s.Addr().String() + "/identity"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	require.NoError(t, err)
+
+	resp, err := newClient(clientCert).Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	id := &ClientIdentity{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(id))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "client", id.CommonName)
+	require.Equal(t, []string{"client.example.org"}, id.DNSNames)
+
+	// the client certificate is required
+	resp, err = newClient().Do(req)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+
+	require.Error(t, err)
+}
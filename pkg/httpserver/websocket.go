@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocketConfig contains the WebSocket handler settings.
+type WebSocketConfig struct {
+	// AllowedOrigins is the list of allowed values of the Origin header (e.g. "https://example.com").
+	// The "*" value allows any origin.
+	// An empty list only allows the requests without the Origin header or from the same host of the request,
+	// to protect against Cross-Site WebSocket Hijacking.
+	AllowedOrigins []string
+
+	// MaxPayloadBytes is the maximum size of the received frames.
+	// A zero value sets the websocket.DefaultMaxPayloadBytes.
+	MaxPayloadBytes int
+}
+
+// WebSocketHandlerFunc is the type of function that handles an upgraded WebSocket connection.
+// The connection is closed when the function returns.
+// The context contains the request logger and trace ID.
+type WebSocketHandlerFunc func(ctx context.Context, ws *websocket.Conn) error
+
+// WebSocketHandler returns a handler that upgrades the HTTP connection to the WebSocket protocol.
+// The server read and write timeouts are removed from the upgraded connection,
+// so the function should set its own deadlines when required (e.g. ws.SetReadDeadline).
+// The connection and disconnection are logged with the request logger.
+func WebSocketHandler(cfg WebSocketConfig, fn WebSocketHandlerFunc) http.HandlerFunc {
+	allowed := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		allowed[o] = struct{}{}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		s := websocket.Server{
+			Handshake: func(_ *websocket.Config, req *http.Request) error {
+				err := checkWebSocketOrigin(req, allowed)
+				if err != nil {
+					logging.FromContext(ctx).Warn("WebSocket handshake rejected", zap.Error(err))
+				}
+
+				return err
+			},
+			Handler: func(ws *websocket.Conn) {
+				serveWebSocket(ctx, ws, cfg.MaxPayloadBytes, fn)
+			},
+		}
+
+		s.ServeHTTP(w, r)
+	}
+}
+
+func serveWebSocket(ctx context.Context, ws *websocket.Conn, maxPayloadBytes int, fn WebSocketHandlerFunc) {
+	l := logging.FromContext(ctx)
+
+	// remove the deadlines set by the server read and write timeouts
+	if err := ws.SetDeadline(time.Time{}); err != nil {
+		l.Error("failed removing the WebSocket deadlines", zap.Error(err))
+	}
+
+	if maxPayloadBytes > 0 {
+		ws.MaxPayloadBytes = maxPayloadBytes
+	}
+
+	l.Debug("WebSocket connected")
+
+	start := time.Now()
+	err := fn(ctx, ws)
+
+	l = l.With(zap.Duration("websocket_duration", time.Since(start)))
+
+	if err != nil {
+		l.Error("WebSocket handler failed", zap.Error(err))
+	} else {
+		l.Debug("WebSocket closed")
+	}
+
+	_ = ws.Close()
+}
+
+// checkWebSocketOrigin checks the Origin header against the allowed origins or the request host.
+func checkWebSocketOrigin(r *http.Request, allowed map[string]struct{}) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	if len(allowed) > 0 {
+		if _, ok := allowed["*"]; ok {
+			return nil
+		}
+
+		if _, ok := allowed[origin]; ok {
+			return nil
+		}
+
+		return fmt.Errorf("WebSocket origin not allowed: %s", origin)
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return fmt.Errorf("WebSocket origin not allowed: %s", origin)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/nexmoinc/gosrvlib/pkg/traceid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+type testWebSocketBinder struct {
+	handler http.HandlerFunc
+}
+
+func (b *testWebSocketBinder) BindHTTP(_ context.Context) []route.Route {
+	return []route.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/ws",
+			Handler: b.handler,
+		},
+	}
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context()
+
+	traceIDs := make(chan string, 1)
+
+	handler := WebSocketHandler(WebSocketConfig{MaxPayloadBytes: 1024}, func(ctx context.Context, ws *websocket.Conn) error {
+		traceIDs <- traceid.FromContext(ctx, "")
+
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		return websocket.Message.Send(ws, "echo: "+msg) //nolint:wrapcheck
+	})
+
+	// the upgraded connection outlives the server read and write timeouts
+	s, err := NewServer(ctx, &testWebSocketBinder{handler: handler},
+		WithServerAddr("127.0.0.1:0"),
+		WithServerWriteTimeout(50*time.Millisecond),
+		WithServerReadTimeout(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	defer func() { _ = s.Shutdown(ctx) }()
+
+	addr := s.Addr().String()
+
+	cfg, err := websocket.NewConfig("ws://"+addr+"/ws", "http://"+addr)
+	require.NoError(t, err)
+
+	cfg.Header.Set(traceid.DefaultHeader, "test-trace-id")
+
+	ws, err := websocket.DialConfig(cfg)
+	require.NoError(t, err)
+
+	defer func() { _ = ws.Close() }()
+
+	require.Equal(t, "test-trace-id", <-traceIDs)
+
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, websocket.Message.Send(ws, "hello"))
+
+	var reply string
+
+	require.NoError(t, websocket.Message.Receive(ws, &reply))
+	require.Equal(t, "echo: hello", reply)
+}
+
+func TestWebSocketHandler_rejected(t *testing.T) {
+	t.Parallel()
+
+	handler := WebSocketHandler(WebSocketConfig{}, func(ctx context.Context, ws *websocket.Conn) error {
+		return errors.New("unexpected")
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	u := strings.Replace(srv.URL, "http://", "ws://", 1)
+
+	_, err := websocket.Dial(u, "", "http://evil.example.com")
+	require.Error(t, err)
+}
+
+func Test_checkWebSocketOrigin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		wantErr bool
+	}{
+		{
+			name: "no origin",
+		},
+		{
+			name:   "same host",
+			origin: "https://example.com",
+		},
+		{
+			name:    "different host",
+			origin:  "https://evil.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "invalid origin",
+			origin:  "://",
+			wantErr: true,
+		},
+		{
+			name:    "allowed origin",
+			origin:  "https://app.example.com",
+			allowed: []string{"https://app.example.com"},
+		},
+		{
+			name:    "not allowed origin",
+			origin:  "https://example.com",
+			allowed: []string{"https://app.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "any origin",
+			origin:  "https://other.com",
+			allowed: []string{"*"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			allowed := make(map[string]struct{})
+			for _, o := range tt.allowed {
+				allowed[o] = struct{}{}
+			}
+
+			req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "http://example.com/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			err := checkWebSocketOrigin(req, allowed)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
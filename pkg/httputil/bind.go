@@ -0,0 +1,329 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/validator"
+)
+
+// DefaultBindMaxBodySize is the default maximum size in bytes of the JSON request body decoded by Bind.
+const DefaultBindMaxBodySize = 1 << 20
+
+// Bind struct tags.
+const (
+	BindTagQuery  = "query"
+	BindTagPath   = "path"
+	BindTagHeader = "header"
+)
+
+var (
+	defaultBindValidator     *validator.Validator
+	defaultBindValidatorOnce sync.Once
+)
+
+// BindOption is the type of function used to set the Bind options.
+type BindOption func(cfg *bindConfig)
+
+type bindConfig struct {
+	maxBodySize           int64
+	disallowUnknownFields bool
+	validator             *validator.Validator
+}
+
+// WithBindMaxBodySize sets the maximum size in bytes of the JSON request body.
+func WithBindMaxBodySize(size int64) BindOption {
+	return func(cfg *bindConfig) {
+		cfg.maxBodySize = size
+	}
+}
+
+// WithBindDisallowUnknownFields rejects the JSON request bodies containing fields not defined in the struct.
+func WithBindDisallowUnknownFields() BindOption {
+	return func(cfg *bindConfig) {
+		cfg.disallowUnknownFields = true
+	}
+}
+
+// WithBindValidator sets the validator used to validate the bound struct.
+// The default validator uses the "json" tag for the field names and the default error templates.
+func WithBindValidator(v *validator.Validator) BindOption {
+	return func(cfg *bindConfig) {
+		cfg.validator = v
+	}
+}
+
+// Bind returns a struct of type T populated with the request data and validated with the "validate" tags.
+//
+// The JSON request body is decoded first, then the fields tagged with `path:"name"`, `query:"name"` and `header:"Name"`
+// are set from the path parameters, URL query parameters and request headers.
+// The tagged fields can be strings, booleans, numbers, time.Duration, encoding.TextUnmarshaler types,
+// or pointers and slices of these types (the slices collect the repeated query parameters and headers).
+//
+// The returned error is a *Problem that can be sent with SendProblem:
+// 400 Bad Request for malformed data, 413 Request Entity Too Large for bodies exceeding the maximum size,
+// 415 Unsupported Media Type for non-JSON bodies, and 422 Unprocessable Entity for validation errors,
+// with the "invalid-params" extension listing the invalid fields.
+func Bind[T any](r *http.Request, opts ...BindOption) (T, error) {
+	var v T
+
+	cfg := &bindConfig{
+		maxBodySize: DefaultBindMaxBodySize,
+	}
+
+	for _, applyOpt := range opts {
+		applyOpt(cfg)
+	}
+
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, NewProblem(http.StatusInternalServerError, fmt.Sprintf("cannot bind the request to the %T type", v))
+	}
+
+	if p := bindBody(r, &v, cfg); p != nil {
+		return v, p
+	}
+
+	names := make(map[string]string)
+
+	if params := bindFields(r, rv, "", names); len(params) > 0 {
+		p := NewProblem(http.StatusBadRequest, "the request contains invalid parameters")
+		return v, p.WithExtension(ProblemExtInvalidParams, params)
+	}
+
+	val := cfg.validator
+	if val == nil {
+		val = bindValidator()
+	}
+
+	if err := val.ValidateStructCtx(r.Context(), &v); err != nil {
+		return v, newValidationProblem(http.StatusUnprocessableEntity, err, func(ve *validator.Error) string {
+			// use the parameter names of the path, query and header fields
+			if _, ns, ok := strings.Cut(ve.StructNamespace, "."); ok {
+				if name, ok := names[ns]; ok {
+					return name
+				}
+			}
+
+			return validationParamName(ve)
+		})
+	}
+
+	return v, nil
+}
+
+func bindValidator() *validator.Validator {
+	defaultBindValidatorOnce.Do(func() {
+		// no errors are returned with the default templates
+		defaultBindValidator, _ = validator.New(
+			validator.WithFieldNameTag("json"),
+			validator.WithErrorTemplates(validator.ErrorTemplates()),
+		)
+	})
+
+	return defaultBindValidator
+}
+
+// bindBody decodes the JSON request body, if any.
+func bindBody(r *http.Request, v interface{}, cfg *bindConfig) *Problem {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mt != "application/json" && !strings.HasSuffix(mt, "+json")) {
+			return NewProblem(http.StatusUnsupportedMediaType, "the request body must be JSON")
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, cfg.maxBodySize+1))
+	if err != nil {
+		return NewProblem(http.StatusBadRequest, "unable to read the request body")
+	}
+
+	if int64(len(body)) > cfg.maxBodySize {
+		return NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body exceeds the maximum size of %d bytes", cfg.maxBodySize))
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+
+	if cfg.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return NewProblem(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+	}
+
+	if dec.More() {
+		return NewProblem(http.StatusBadRequest, "invalid JSON body: unexpected data after the JSON object")
+	}
+
+	return nil
+}
+
+// bindFields sets the fields tagged with path, query and header, returning the invalid parameters.
+// The parameter names are collected in the names map, indexed by the field namespace.
+func bindFields(r *http.Request, rv reflect.Value, namespace string, names map[string]string) []InvalidParam {
+	var params []InvalidParam
+
+	rt := rv.Type()
+	query := r.URL.Query()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			// the exported fields of the embedded structs are settable even when the struct type is unexported
+			params = append(params, bindFields(r, fv, namespace+f.Name+".", names)...)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		var (
+			name   string
+			values []string
+		)
+
+		switch {
+		case f.Tag.Get(BindTagPath) != "":
+			name = f.Tag.Get(BindTagPath)
+			if s := PathParam(r, name); s != "" {
+				values = []string{s}
+			}
+		case f.Tag.Get(BindTagQuery) != "":
+			name = f.Tag.Get(BindTagQuery)
+			values = query[name]
+		case f.Tag.Get(BindTagHeader) != "":
+			name = f.Tag.Get(BindTagHeader)
+			values = r.Header.Values(name)
+		default:
+			continue
+		}
+
+		names[namespace+f.Name] = name
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setFieldValues(fv, values); err != nil {
+			params = append(params, InvalidParam{Name: name, Reason: err.Error()})
+		}
+	}
+
+	return params
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+func setFieldValues(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Slice && !reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(fv.Type(), len(values), len(values))
+
+		for i, value := range values {
+			if err := setFieldValue(s.Index(i), value); err != nil {
+				return err
+			}
+		}
+
+		fv.Set(s)
+
+		return nil
+	}
+
+	return setFieldValue(fv, values[0])
+}
+
+//nolint:gocyclo,exhaustive
+func setFieldValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Ptr {
+		pv := reflect.New(fv.Type().Elem())
+		if err := setFieldValue(pv.Elem(), value); err != nil {
+			return err
+		}
+
+		fv.Set(pv)
+
+		return nil
+	}
+
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		if err := fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+
+		return nil
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("invalid duration")
+		}
+
+		fv.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid boolean")
+		}
+
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer")
+		}
+
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid unsigned integer")
+		}
+
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return errors.New("invalid number")
+		}
+
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/nexmoinc/gosrvlib/pkg/validator"
+	"github.com/stretchr/testify/require"
+)
+
+type testBindPaging struct {
+	Page  int  `query:"page" validate:"omitempty,min=1"`
+	Limit *int `query:"limit" validate:"omitempty,max=100"`
+}
+
+type testBindRequest struct {
+	testBindPaging
+
+	ID      uint64        `path:"id" json:"-" validate:"required"`
+	Tags    []string      `query:"tag" json:"-"`
+	Since   time.Time     `query:"since" json:"-"`
+	Timeout time.Duration `header:"X-Timeout" json:"-"`
+	Debug   bool          `header:"X-Debug" json:"-"`
+	Ratio   float32       `query:"ratio" json:"-"`
+	Level   int8          `query:"level" json:"-"`
+	Name    string        `json:"name" validate:"required"`
+	Email   string        `json:"email" validate:"omitempty,email"`
+}
+
+func newBindRequest(t *testing.T, target, body string, params httprouter.Params) *http.Request {
+	t.Helper()
+
+	ctx := context.WithValue(testutil.Context(), httprouter.ParamsKey, params)
+
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(http.MethodPost, target, nil)
+	} else {
+		req = httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req.WithContext(ctx)
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	req := newBindRequest(t,
+		"/items/42?page=2&limit=10&tag=a&tag=b&since=2022-01-02T03:04:05Z&ratio=0.5&level=-3",
+		`{"name":"alpha","email":"alpha@example.com"}`,
+		httprouter.Params{{Key: "id", Value: "42"}},
+	)
+	req.Header.Set("X-Timeout", "1m30s")
+	req.Header.Set("X-Debug", "true")
+
+	got, err := Bind[testBindRequest](req)
+	require.NoError(t, err)
+
+	limit := 10
+	exp := testBindRequest{
+		testBindPaging: testBindPaging{Page: 2, Limit: &limit},
+		ID:             42,
+		Tags:           []string{"a", "b"},
+		Since:          time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout:        90 * time.Second,
+		Debug:          true,
+		Ratio:          0.5,
+		Level:          -3,
+		Name:           "alpha",
+		Email:          "alpha@example.com",
+	}
+	require.Equal(t, exp, got)
+}
+
+func TestBind_errors(t *testing.T) {
+	t.Parallel()
+
+	params := httprouter.Params{{Key: "id", Value: "42"}}
+
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		contentType string
+		params      httprouter.Params
+		opts        []BindOption
+		wantStatus  int
+		wantParams  []string
+	}{
+		{
+			name:       "invalid parameters",
+			target:     "/items/x?page=x&limit=-&since=yesterday&ratio=x&level=300",
+			body:       `{"name":"alpha"}`,
+			params:     httprouter.Params{{Key: "id", Value: "x"}},
+			wantStatus: http.StatusBadRequest,
+			wantParams: []string{"page", "limit", "id", "since", "ratio", "level"},
+		},
+		{
+			name:       "invalid JSON",
+			target:     "/items/42",
+			body:       `{"name":`,
+			params:     params,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "trailing data",
+			target:     "/items/42",
+			body:       `{"name":"alpha"} {}`,
+			params:     params,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field",
+			target:     "/items/42",
+			body:       `{"name":"alpha","unknown":1}`,
+			params:     params,
+			opts:       []BindOption{WithBindDisallowUnknownFields()},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "body too large",
+			target:     "/items/42",
+			body:       `{"name":"alpha"}`,
+			params:     params,
+			opts:       []BindOption{WithBindMaxBodySize(8)},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "unsupported media type",
+			target:      "/items/42",
+			body:        `name=alpha`,
+			contentType: "application/x-www-form-urlencoded",
+			params:      params,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "validation errors",
+			target:     "/items/0?page=0&limit=1000",
+			body:       `{"email":"invalid"}`,
+			params:     httprouter.Params{{Key: "id", Value: "0"}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantParams: []string{"limit", "id", "name", "email"},
+		},
+		{
+			name:       "custom validator",
+			target:     "/items/42",
+			body:       `{"email":"invalid"}`,
+			params:     params,
+			opts:       []BindOption{WithBindValidator(testBindValidator(t))},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantParams: []string{"Name", "Email"},
+		},
+		{
+			name:       "custom validator with parameters",
+			target:     "/items/0?page=-1",
+			body:       `{"name":"alpha"}`,
+			params:     httprouter.Params{{Key: "id", Value: "0"}},
+			opts:       []BindOption{WithBindValidator(testBindValidator(t))},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantParams: []string{"page", "id"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := newBindRequest(t, tt.target, tt.body, tt.params)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			_, err := Bind[testBindRequest](req, tt.opts...)
+			require.Error(t, err)
+
+			var p *Problem
+
+			require.True(t, errors.As(err, &p))
+			require.Equal(t, tt.wantStatus, p.Status)
+
+			if tt.wantParams == nil {
+				require.Nil(t, p.Extensions)
+				return
+			}
+
+			invalid, ok := p.Extensions[ProblemExtInvalidParams].([]InvalidParam)
+			require.True(t, ok)
+
+			names := make([]string, 0, len(invalid))
+			for _, ip := range invalid {
+				names = append(names, ip.Name)
+				require.NotEmpty(t, ip.Reason)
+			}
+
+			require.Equal(t, tt.wantParams, names)
+		})
+	}
+}
+
+func TestBind_noBody(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Page int `query:"page"`
+	}
+
+	got, err := Bind[request](newBindRequest(t, "/?page=3", "", nil))
+	require.NoError(t, err)
+	require.Equal(t, request{Page: 3}, got)
+}
+
+func TestBind_unsupportedType(t *testing.T) {
+	t.Parallel()
+
+	_, err := Bind[int](newBindRequest(t, "/", "", nil))
+	require.Error(t, err)
+
+	type request struct {
+		Data map[string]string `query:"data"`
+	}
+
+	_, err = Bind[request](newBindRequest(t, "/?data=x", "", nil))
+	require.Error(t, err)
+}
+
+func testBindValidator(t *testing.T) *validator.Validator {
+	t.Helper()
+
+	v, err := validator.New()
+	require.NoError(t, err)
+
+	return v
+}
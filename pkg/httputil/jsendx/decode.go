@@ -0,0 +1,155 @@
+package jsendx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+)
+
+var (
+	// ErrInvalidResponse is returned by Decode when the response is not a valid JSendX envelope.
+	ErrInvalidResponse = errors.New("invalid JSendX response")
+
+	// ErrFail matches (with errors.Is) the *Error returned for the "fail" responses (4xx status codes).
+	ErrFail = errors.New("JSendX fail response")
+
+	// ErrError matches (with errors.Is) the *Error returned for the "error" responses (5xx status codes).
+	ErrError = errors.New("JSendX error response")
+)
+
+// HTTPClient contains the function to perform the HTTP requests (e.g. httpclient.Client or http.Client).
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Error is the error returned by Decode for the JSendX responses with "fail" or "error" status.
+type Error struct {
+	// Status is the JSend status string (i.e.: fail, error).
+	Status string
+
+	// Code is the HTTP status code number of the envelope.
+	Code int
+
+	// Message is the error or general HTTP status message.
+	Message string
+
+	// Data is the raw JSON content payload, usually containing the error details.
+	Data json.RawMessage
+}
+
+// Error returns a string representation of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSendX %s response: %d %s", e.Status, e.Code, e.Message)
+}
+
+// Is reports whether the error matches the ErrFail or ErrError target.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrFail:
+		return e.Status == httputil.StatusFail
+	case ErrError:
+		return e.Status == httputil.StatusError
+	}
+
+	return false
+}
+
+// TypedResponse is a JSendX response with the data payload decoded as type T, returned by Decode and Do.
+// Response is not generic to preserve the compatibility of Wrap and Send with the existing callers,
+// while the common fields are shared through Meta.
+type TypedResponse[T any] struct {
+	Meta
+
+	// Data is the content payload.
+	Data T `json:"data"`
+}
+
+// envelope is used to decode the JSendX response with the JSend status string.
+type envelope struct {
+	TypedResponse[json.RawMessage]
+	Status string `json:"status"`
+}
+
+// Decode reads and closes the body of a JSendX response returned by an upstream service,
+// returning the response with the data payload decoded as type T.
+//
+// The envelope is validated: the status must be consistent with the code, and the code must match the HTTP
+// status code of the response, otherwise an error wrapping ErrInvalidResponse is returned
+// (e.g. for the interrupted streams, see NewStream).
+// The "fail" and "error" responses are returned as *Error, matching ErrFail and ErrError respectively.
+func Decode[T any](resp *http.Response) (*TypedResponse[T], error) {
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the response body: %w", err)
+	}
+
+	var env envelope
+
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("%w (HTTP status %d): %v", ErrInvalidResponse, resp.StatusCode, err)
+	}
+
+	if err := validateEnvelope(&env, resp.StatusCode); err != nil {
+		return nil, fmt.Errorf("%w (HTTP status %d): %v", ErrInvalidResponse, resp.StatusCode, err)
+	}
+
+	if env.Status != httputil.StatusSuccess {
+		return nil, &Error{
+			Status:  env.Status,
+			Code:    env.Code,
+			Message: env.Message,
+			Data:    env.Data,
+		}
+	}
+
+	r := &TypedResponse[T]{Meta: env.Meta}
+	r.Status = httputil.Status(env.Code)
+
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, &r.Data); err != nil {
+			return nil, fmt.Errorf("%w: unable to decode the data: %v", ErrInvalidResponse, err)
+		}
+	}
+
+	return r, nil
+}
+
+// Do performs the HTTP request with the specified client (e.g. httpclient.Client)
+// and decodes the JSendX response with Decode.
+func Do[T any](c HTTPClient, r *http.Request) (*TypedResponse[T], error) {
+	resp, err := c.Do(r)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return Decode[T](resp)
+}
+
+func validateEnvelope(env *envelope, statusCode int) error {
+	if env.Status == "" {
+		return errors.New("missing status")
+	}
+
+	if env.Code < 100 || env.Code > 999 {
+		return fmt.Errorf("invalid code %d", env.Code)
+	}
+
+	// the status must match the one set by Wrap for the code
+	exp, _ := httputil.Status(env.Code).MarshalText() // no errors are returned
+
+	if env.Status != string(exp) {
+		return fmt.Errorf("the status %q does not match the code %d", env.Status, env.Code)
+	}
+
+	if env.Code != statusCode {
+		return fmt.Errorf("the code %d does not match the HTTP status", env.Code)
+	}
+
+	return nil
+}
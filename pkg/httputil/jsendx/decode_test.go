@@ -0,0 +1,222 @@
+package jsendx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nexmoinc/gosrvlib/pkg/httpclient"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testDecodeData struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type testErrorReader struct{}
+
+func (testErrorReader) Read(_ []byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func newTestDecodeResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	info := &AppInfo{
+		ProgramName:    "test",
+		ProgramVersion: "1.2.3",
+		ProgramRelease: "12345",
+	}
+
+	rr := httptest.NewRecorder()
+	Send(testutil.Context(), rr, http.StatusOK, info, testDecodeData{ID: 1, Name: "alpha"})
+
+	got, err := Decode[testDecodeData](rr.Result()) //nolint:bodyclose
+	require.NoError(t, err)
+	require.Equal(t, "test", got.Program)
+	require.Equal(t, "1.2.3", got.Version)
+	require.Equal(t, "12345", got.Release)
+	require.NotEmpty(t, got.DateTime)
+	require.NotZero(t, got.Timestamp)
+	require.Equal(t, httputil.Status(http.StatusOK), got.Status)
+	require.Equal(t, http.StatusOK, got.Code)
+	require.Equal(t, "OK", got.Message)
+	require.Equal(t, testDecodeData{ID: 1, Name: "alpha"}, got.Data)
+}
+
+func TestDecode_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    error
+		wantStatus string
+		wantCode   int
+		wantData   string
+	}{
+		{
+			name:       "fail",
+			statusCode: http.StatusBadRequest,
+			body:       `{"status":"fail","code":400,"message":"Bad Request","data":{"name":"required"}}`,
+			wantErr:    ErrFail,
+			wantStatus: httputil.StatusFail,
+			wantCode:   http.StatusBadRequest,
+			wantData:   `{"name":"required"}`,
+		},
+		{
+			name:       "error",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{"status":"error","code":503,"message":"Service Unavailable","data":"database error"}`,
+			wantErr:    ErrError,
+			wantStatus: httputil.StatusError,
+			wantCode:   http.StatusServiceUnavailable,
+			wantData:   `"database error"`,
+		},
+		{
+			name:       "invalid JSON",
+			statusCode: http.StatusBadGateway,
+			body:       `<html>Bad Gateway</html>`,
+			wantErr:    ErrInvalidResponse,
+		},
+		{
+			name:       "missing status",
+			statusCode: http.StatusOK,
+			body:       `{"code":200,"data":{}}`,
+			wantErr:    ErrInvalidResponse,
+		},
+		{
+			name:       "invalid code",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","data":{}}`,
+			wantErr:    ErrInvalidResponse,
+		},
+		{
+			name:       "status not matching the code",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","code":500,"data":{}}`,
+			wantErr:    ErrInvalidResponse,
+		},
+		{
+			name:       "code not matching the HTTP status",
+			statusCode: http.StatusOK,
+			body:       `{"status":"error","code":500,"message":"stream interrupted","data":[]}`,
+			wantErr:    ErrInvalidResponse,
+		},
+		{
+			name:       "invalid data",
+			statusCode: http.StatusOK,
+			body:       `{"status":"success","code":200,"data":"alpha"}`,
+			wantErr:    ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Decode[testDecodeData](newTestDecodeResponse(tt.statusCode, tt.body)) //nolint:bodyclose
+			require.Nil(t, got)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, tt.wantErr))
+
+			var e *Error
+			if !errors.As(err, &e) {
+				require.Empty(t, tt.wantStatus)
+				return
+			}
+
+			require.Equal(t, tt.wantStatus, e.Status)
+			require.Equal(t, tt.wantCode, e.Code)
+			require.NotEmpty(t, e.Message)
+			require.JSONEq(t, tt.wantData, string(e.Data))
+			require.NotEmpty(t, e.Error())
+			require.False(t, errors.Is(err, ErrInvalidResponse))
+		})
+	}
+}
+
+func TestDecode_readError(t *testing.T) {
+	t.Parallel()
+
+	resp := &http.Response{Body: io.NopCloser(testErrorReader{})}
+
+	_, err := Decode[testDecodeData](resp) //nolint:bodyclose
+	require.Error(t, err)
+}
+
+func TestDecode_nullData(t *testing.T) {
+	t.Parallel()
+
+	got, err := Decode[*testDecodeData](newTestDecodeResponse(http.StatusOK, `{"status":"success","code":200,"data":null}`)) //nolint:bodyclose
+	require.NoError(t, err)
+	require.Nil(t, got.Data)
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	info := &AppInfo{ProgramName: "test"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			Send(r.Context(), w, http.StatusNotFound, info, "invalid endpoint")
+			return
+		}
+
+		Send(r.Context(), w, http.StatusOK, info, []testDecodeData{{ID: 1, Name: "alpha"}, {ID: 2, Name: "beta"}})
+	}))
+	defer srv.Close()
+
+	c := httpclient.New()
+	ctx := testutil.Context()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/items", nil)
+	require.NoError(t, err)
+
+	got, err := Do[[]testDecodeData](c, req)
+	require.NoError(t, err)
+	require.Len(t, got.Data, 2)
+	require.Equal(t, "beta", got.Data[1].Name)
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/missing", nil)
+	require.NoError(t, err)
+
+	_, err = Do[[]testDecodeData](c, req)
+	require.True(t, errors.Is(err, ErrFail))
+
+	var e *Error
+
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, http.StatusNotFound, e.Code)
+
+	var msg string
+
+	require.NoError(t, json.Unmarshal(e.Data, &msg))
+	require.Equal(t, "invalid endpoint", msg)
+
+	// transport errors are returned as they are
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "http://invalid.invalid:0", nil)
+	require.NoError(t, err)
+
+	_, err = Do[[]testDecodeData](c, req)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrInvalidResponse))
+}
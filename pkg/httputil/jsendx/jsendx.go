@@ -3,8 +3,11 @@ package jsendx
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -18,36 +21,49 @@ import (
 
 const (
 	okMessage = "OK"
+
+	// streamErrorSuffix closes the data array of an interrupted stream and overrides the status fields.
+	streamErrorSuffix = `],"status":"error","code":500,"message":"stream interrupted"}`
 )
 
-// Response wraps data into a JSend compliant response.
-type Response struct {
+// Meta contains the fields of a JSendX response, except the data payload.
+// It is shared by Response and TypedResponse.
+type Meta struct {
 	// Program is the application name.
-	Program string `json:"program"`
+	Program string `json:"program" xml:"program"`
 
 	// Version is the program semantic version (e.g. 1.2.3).
-	Version string `json:"version"`
+	Version string `json:"version" xml:"version"`
 
 	// Release is the program build number that is appended to the version.
-	Release string `json:"release"`
+	Release string `json:"release" xml:"release"`
 
 	// DateTime is the human-readable date and time when the response is sent.
-	DateTime string `json:"datetime"`
+	DateTime string `json:"datetime" xml:"datetime"`
 
 	// Timestamp is the machine-readable UTC timestamp in nanoseconds since EPOCH.
-	Timestamp int64 `json:"timestamp"`
+	Timestamp int64 `json:"timestamp" xml:"timestamp"`
 
 	// Status code string (i.e.: error, fail, success).
-	Status httputil.Status `json:"status"`
+	Status httputil.Status `json:"status" xml:"status"`
 
 	// Code is the HTTP status code number.
-	Code int `json:"code"`
+	Code int `json:"code" xml:"code"`
 
 	// Message is the error or general HTTP status message.
-	Message string `json:"message"`
+	Message string `json:"message" xml:"message"`
+}
+
+// Response wraps data into a JSend compliant response.
+type Response struct {
+	// XMLName is the root element name of the XML encoded response.
+	XMLName xml.Name `json:"-" xml:"response"`
+
+	Meta
 
 	// Data is the content payload.
-	Data interface{} `json:"data"`
+	// This must be the last field, as the data array is streamed at the end of the response by NewStream.
+	Data interface{} `json:"data" xml:"data"`
 }
 
 // AppInfo is a struct containing data to enrich the JSendX response.
@@ -62,15 +78,17 @@ func Wrap(statusCode int, info *AppInfo, data interface{}) *Response {
 	now := time.Now().UTC()
 
 	return &Response{
-		Program:   info.ProgramName,
-		Version:   info.ProgramVersion,
-		Release:   info.ProgramRelease,
-		DateTime:  now.Format(time.RFC3339),
-		Timestamp: now.UnixNano(),
-		Status:    httputil.Status(statusCode),
-		Code:      statusCode,
-		Message:   http.StatusText(statusCode),
-		Data:      data,
+		Meta: Meta{
+			Program:   info.ProgramName,
+			Version:   info.ProgramVersion,
+			Release:   info.ProgramRelease,
+			DateTime:  now.Format(time.RFC3339),
+			Timestamp: now.UnixNano(),
+			Status:    httputil.Status(statusCode),
+			Code:      statusCode,
+			Message:   http.StatusText(statusCode),
+		},
+		Data: data,
 	}
 }
 
@@ -79,6 +97,31 @@ func Send(ctx context.Context, w http.ResponseWriter, statusCode int, info *AppI
 	httputil.SendJSON(ctx, w, statusCode, Wrap(statusCode, info, data))
 }
 
+// SendResponse sends a response wrapped in a JSendX container,
+// encoded in the format selected with the Accept request header (see httputil.SendResponse).
+func SendResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, statusCode int, info *AppInfo, data interface{}) {
+	httputil.SendResponse(ctx, w, r, statusCode, Wrap(statusCode, info, data))
+}
+
+// NewStream sends a JSendX container with the data field opened as a JSON array,
+// to stream the items of a large result set with the Write method (see httputil.NewJSONStream).
+// The stream must be terminated by calling Close.
+// When the stream is closed with an error, the data array is closed and followed by the
+// "status":"error", "code":500 and "message" fields, overriding the ones already sent.
+// As the code does not match the HTTP status code, the interrupted streams are rejected by Decode.
+func NewStream(ctx context.Context, w http.ResponseWriter, statusCode int, info *AppInfo, opts ...httputil.StreamOption) *httputil.Stream {
+	// the data field is the last one of the encoded response (see Response.Data)
+	b, _ := json.Marshal(Wrap(statusCode, info, nil)) // no errors are returned with nil data
+	prefix := strings.TrimSuffix(string(b), "null}")
+
+	opts = append([]httputil.StreamOption{
+		httputil.WithStreamEnvelope(prefix, "}"),
+		httputil.WithStreamErrorSuffix(streamErrorSuffix),
+	}, opts...)
+
+	return httputil.NewJSONStream(ctx, w, statusCode, opts...)
+}
+
 // NewRouter create a new router configured to responds with JSend wrapper responses for 404, 405 and panic.
 func NewRouter(info *AppInfo, instrumentHandler httpserver.InstrumentHandler) *httprouter.Router {
 	r := httprouter.New()
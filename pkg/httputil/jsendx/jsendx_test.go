@@ -3,16 +3,20 @@ package jsendx
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver"
 	"github.com/nexmoinc/gosrvlib/pkg/httpserver/route"
+	"github.com/nexmoinc/gosrvlib/pkg/httputil"
 	"github.com/nexmoinc/gosrvlib/pkg/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -59,6 +63,107 @@ func TestSend(t *testing.T) {
 	Send(testutil.Context(), mockWriter, http.StatusOK, params, "message")
 }
 
+func TestSendResponse(t *testing.T) {
+	t.Parallel()
+
+	params := &AppInfo{
+		ProgramName:    "test",
+		ProgramVersion: "1.2.3",
+		ProgramRelease: "12345",
+	}
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	SendResponse(testutil.Context(), rr, req, http.StatusNotFound, params, "hello test")
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	require.Equal(t, httputil.MimeApplicationXML, rr.Header().Get("Content-Type"))
+
+	var resp struct {
+		Program string `xml:"program"`
+		Code    int    `xml:"code"`
+		Message string `xml:"message"`
+	}
+
+	require.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "test", resp.Program)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	require.Equal(t, "Not Found", resp.Message)
+	require.Contains(t, rr.Body.String(), "<status>fail</status>")
+	require.Contains(t, rr.Body.String(), "<data>hello test</data>")
+}
+
+func TestNewStream(t *testing.T) {
+	t.Parallel()
+
+	params := &AppInfo{
+		ProgramName:    "test",
+		ProgramVersion: "1.2.3",
+		ProgramRelease: "12345",
+	}
+
+	rr := httptest.NewRecorder()
+	s := NewStream(testutil.Context(), rr, http.StatusOK, params, httputil.WithStreamFlushItems(1))
+
+	require.NoError(t, s.Write("a"))
+	require.NoError(t, s.Write("b"))
+	require.NoError(t, s.Close(nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, rr.Flushed)
+
+	var resp struct {
+		Program string   `json:"program"`
+		Status  string   `json:"status"`
+		Code    int      `json:"code"`
+		Data    []string `json:"data"`
+	}
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "test", resp.Program)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, []string{"a", "b"}, resp.Data)
+}
+
+func TestNewStream_interrupted(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	s := NewStream(testutil.Context(), rr, http.StatusOK, &AppInfo{ProgramName: "test"})
+
+	require.NoError(t, s.Write("a"))
+	require.NoError(t, s.Close(errors.New("database error")))
+
+	var resp struct {
+		Status  string   `json:"status"`
+		Code    int      `json:"code"`
+		Message string   `json:"message"`
+		Data    []string `json:"data"`
+	}
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "error", resp.Status)
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.Equal(t, "stream interrupted", resp.Message)
+	require.Equal(t, []string{"a"}, resp.Data)
+
+	// the code does not match the HTTP status already sent
+	_, err := Decode[[]string](rr.Result()) //nolint:bodyclose
+	require.True(t, errors.Is(err, ErrInvalidResponse))
+}
+
+func TestWrap_dataLastField(t *testing.T) {
+	t.Parallel()
+
+	// NewStream requires the data field to be the last one of the encoded response
+	b, err := json.Marshal(Wrap(http.StatusOK, &AppInfo{}, nil))
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(string(b), `,"data":null}`))
+}
+
 func TestNewRouter(t *testing.T) {
 	t.Parallel()
 
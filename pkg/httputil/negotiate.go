@@ -0,0 +1,387 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// MimeTextCSV contains the mime type string for CSV content.
+	MimeTextCSV = "text/csv; charset=utf-8"
+
+	// MimeApplicationNDJSON contains the mime type string for Newline Delimited JSON content.
+	MimeApplicationNDJSON = "application/x-ndjson"
+)
+
+// ErrUnsupportedData is returned by an EncoderFunc when the data type is not supported by the format,
+// so the next acceptable encoder can be selected.
+var ErrUnsupportedData = errors.New("unsupported data type")
+
+// EncoderFunc is the type of function used to encode the response data.
+type EncoderFunc func(w io.Writer, data interface{}) error
+
+type encoder struct {
+	mediaType   string
+	contentType string
+	fn          EncoderFunc
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = []encoder{
+		{mediaType: "application/json", contentType: MimeApplicationJSON, fn: encodeJSON},
+		{mediaType: "application/xml", contentType: MimeApplicationXML, fn: encodeXML},
+		{mediaType: "text/plain", contentType: MimeTextPlain, fn: encodeText},
+		{mediaType: "text/csv", contentType: MimeTextCSV, fn: encodeCSV},
+		{mediaType: "application/x-ndjson", contentType: MimeApplicationNDJSON, fn: encodeNDJSON},
+	}
+)
+
+// RegisterEncoder registers the encoder used by SendResponse for the specified media type (e.g. "application/msgpack"),
+// replacing any existing encoder for the same media type.
+// The contentType is the Content-Type header value of the response.
+// When the client accepts multiple media types with the same quality,
+// the encoders are selected in registration order, starting with the default ones:
+// JSON, XML, plain text, CSV and NDJSON.
+func RegisterEncoder(mediaType, contentType string, fn EncoderFunc) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].contentType = contentType
+			encoders[i].fn = fn
+
+			return
+		}
+	}
+
+	encoders = append(encoders, encoder{mediaType: mediaType, contentType: contentType, fn: fn})
+}
+
+// SendResponse sends the data encoded in the format selected with the Accept request header.
+// The media types are selected by quality value, and the default JSON format is used when any type is accepted.
+// The encoders returning ErrUnsupportedData are skipped (e.g. CSV only supports slices of structs),
+// and 406 Not Acceptable is returned when no encoder can be selected.
+func SendResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	w.Header().Add("Vary", "Accept")
+
+	for _, enc := range acceptedEncoders(r.Header.Get("Accept")) {
+		var buf bytes.Buffer
+
+		err := enc.fn(&buf, data)
+		if errors.Is(err, ErrUnsupportedData) {
+			continue
+		}
+
+		if err != nil {
+			logging.FromContext(ctx).Error("httputil.SendResponse()", zap.String("content_type", enc.contentType), zap.Error(err))
+			SendStatus(ctx, w, http.StatusInternalServerError)
+
+			return
+		}
+
+		defer logResponse(ctx, statusCode, logKeyResponseDataObject, data)
+
+		writeHeaders(w, statusCode, enc.contentType)
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			logging.FromContext(ctx).Error("httputil.SendResponse()", zap.Error(err))
+		}
+
+		return
+	}
+
+	SendStatus(ctx, w, http.StatusNotAcceptable)
+}
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	mediaType string
+	subType   string
+	quality   float64
+}
+
+// parseAccept returns the valid media ranges of the Accept header.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, item := range strings.Split(header, ",") {
+		params := strings.Split(item, ";")
+
+		mediaType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || mediaType == "" || subType == "" || (mediaType == "*" && subType != "*") {
+			continue
+		}
+
+		ar := acceptRange{mediaType: mediaType, subType: subType, quality: 1}
+
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.ToLower(strings.TrimSpace(k)) != "q" {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || q < 0 || q > 1 {
+				ok = false
+				break
+			}
+
+			ar.quality = q
+		}
+
+		if ok {
+			ranges = append(ranges, ar)
+		}
+	}
+
+	return ranges
+}
+
+// quality returns the quality value of the most specific media range matching the media type.
+func quality(ranges []acceptRange, mediaType string) float64 {
+	typ, sub, _ := strings.Cut(mediaType, "/")
+
+	q := 0.0
+	specificity := -1
+
+	for _, ar := range ranges {
+		var s int
+
+		switch {
+		case ar.mediaType == typ && ar.subType == sub:
+			s = 2
+		case ar.mediaType == typ && ar.subType == "*":
+			s = 1
+		case ar.mediaType == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			specificity = s
+			q = ar.quality
+		}
+	}
+
+	return q
+}
+
+// acceptedEncoders returns the encoders accepted by the Accept header, sorted by quality value.
+func acceptedEncoders(header string) []encoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	if strings.TrimSpace(header) == "" {
+		return append([]encoder(nil), encoders...)
+	}
+
+	ranges := parseAccept(header)
+
+	type candidate struct {
+		enc     encoder
+		quality float64
+	}
+
+	candidates := make([]candidate, 0, len(encoders))
+
+	for _, enc := range encoders {
+		if q := quality(ranges, enc.mediaType); q > 0 {
+			candidates = append(candidates, candidate{enc: enc, quality: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	list := make([]encoder, len(candidates))
+	for i, c := range candidates {
+		list[i] = c.enc
+	}
+
+	return list
+}
+
+func encodeJSON(w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data) //nolint:wrapcheck
+}
+
+func encodeXML(w io.Writer, data interface{}) error {
+	if _, err := io.WriteString(w, XMLHeader); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	err := xml.NewEncoder(w).Encode(data)
+
+	var ute *xml.UnsupportedTypeError
+	if errors.As(err, &ute) {
+		return fmt.Errorf("%w: %v", ErrUnsupportedData, err)
+	}
+
+	return err //nolint:wrapcheck
+}
+
+// encodeText supports strings, byte slices, errors, text marshalers, stringers and scalar values.
+func encodeText(w io.Writer, data interface{}) error {
+	var s string
+
+	switch v := data.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case error:
+		s = v.Error()
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		s = string(b)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		switch reflect.ValueOf(data).Kind() { //nolint:exhaustive
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			s = fmt.Sprint(data)
+		default:
+			return ErrUnsupportedData
+		}
+	}
+
+	_, err := io.WriteString(w, s)
+
+	return err //nolint:wrapcheck
+}
+
+// encodeCSV supports slices and arrays of structs, with a header row containing the field names.
+// The field names can be changed with the `csv` tag, while the fields tagged with `csv:"-"` are skipped.
+func encodeCSV(w io.Writer, data interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return ErrUnsupportedData
+	}
+
+	et := v.Type().Elem()
+	if et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+
+	if et.Kind() != reflect.Struct {
+		return ErrUnsupportedData
+	}
+
+	var (
+		fields []int
+		header []string
+	)
+
+	for i := 0; i < et.NumField(); i++ {
+		f := et.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("csv"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, i)
+		header = append(header, name)
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(header); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+
+		record := make([]string, len(fields))
+		for j, idx := range fields {
+			record[j] = csvValue(item.Field(idx))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error() //nolint:wrapcheck
+}
+
+func csvValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	switch t := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		if b, err := t.MarshalText(); err == nil {
+			return string(b)
+		}
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// encodeNDJSON encodes each element of slices and arrays as a JSON line, or any other value as a single line.
+func encodeNDJSON(w io.Writer, data interface{}) error {
+	enc := json.NewEncoder(w)
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Type().Elem().Kind() == reflect.Uint8 {
+		return enc.Encode(data) //nolint:wrapcheck
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err //nolint:wrapcheck
+		}
+	}
+
+	return nil
+}
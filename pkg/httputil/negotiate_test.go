@@ -0,0 +1,245 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testNegotiateItem struct {
+	ID      int       `json:"id" csv:"id"`
+	Name    string    `json:"name" csv:"name"`
+	Tags    *string   `json:"tags,omitempty"`
+	Created time.Time `json:"-" csv:"created,omitempty"`
+	Secret  string    `json:"-" csv:"-"`
+	private string
+}
+
+type testStringer struct{}
+
+func (testStringer) String() string { return "stringer" }
+
+func TestSendResponse(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	tags := "a,b"
+	items := []*testNegotiateItem{
+		{ID: 1, Name: "alpha", Created: created, Secret: "x", private: "y"},
+		nil,
+		{ID: 2, Name: "beta", Tags: &tags, Created: created},
+	}
+
+	tests := []struct {
+		name       string
+		accept     string
+		data       interface{}
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{
+			name:       "no accept header",
+			data:       map[string]int{"a": 1},
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationJSON,
+			wantBody:   "{\"a\":1}\n",
+		},
+		{
+			name:       "any type",
+			accept:     "*/*",
+			data:       "hello",
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationJSON,
+			wantBody:   "\"hello\"\n",
+		},
+		{
+			name:       "xml",
+			accept:     "application/xml",
+			data:       "hello",
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationXML,
+			wantBody:   XMLHeader + "<string>hello</string>",
+		},
+		{
+			name:       "xml not supported fallback",
+			accept:     "application/xml, application/json;q=0.5",
+			data:       map[string]int{"a": 1},
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationJSON,
+			wantBody:   "{\"a\":1}\n",
+		},
+		{
+			name:       "quality values",
+			accept:     "application/json;q=0.5, text/plain;q=0.9, text/*;q=0.1",
+			data:       42,
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextPlain,
+			wantBody:   "42",
+		},
+		{
+			name:       "more specific range wins",
+			accept:     "text/*;q=0.9, text/plain;q=0, */*;q=0.1",
+			data:       "hello",
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationJSON,
+			wantBody:   "\"hello\"\n",
+		},
+		{
+			name:       "text stringer",
+			accept:     "text/plain",
+			data:       testStringer{},
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextPlain,
+			wantBody:   "stringer",
+		},
+		{
+			name:       "text error",
+			accept:     "text/plain",
+			data:       errors.New("failure"),
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextPlain,
+			wantBody:   "failure",
+		},
+		{
+			name:       "text bytes",
+			accept:     "text/plain",
+			data:       []byte("raw"),
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextPlain,
+			wantBody:   "raw",
+		},
+		{
+			name:       "text marshaler",
+			accept:     "text/plain",
+			data:       created,
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextPlain,
+			wantBody:   "2022-01-02T03:04:05Z",
+		},
+		{
+			name:       "text not supported",
+			accept:     "text/plain",
+			data:       items,
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "csv",
+			accept:     "text/csv",
+			data:       items,
+			wantStatus: http.StatusOK,
+			wantType:   MimeTextCSV,
+			wantBody:   "id,name,Tags,created\n1,alpha,,2022-01-02T03:04:05Z\n2,beta,\"a,b\",2022-01-02T03:04:05Z\n",
+		},
+		{
+			name:       "csv not supported",
+			accept:     "text/csv",
+			data:       []int{1, 2},
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "ndjson",
+			accept:     "application/x-ndjson",
+			data:       []int{1, 2, 3},
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationNDJSON,
+			wantBody:   "1\n2\n3\n",
+		},
+		{
+			name:       "ndjson single value",
+			accept:     "application/x-ndjson",
+			data:       map[string]int{"a": 1},
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationNDJSON,
+			wantBody:   "{\"a\":1}\n",
+		},
+		{
+			name:       "not acceptable",
+			accept:     "image/png, application/json;q=0",
+			data:       "hello",
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "invalid ranges are ignored",
+			accept:     "invalid, */json, text/plain;q=2, application/xml;q=x, application/json;q=0.1",
+			data:       "hello",
+			wantStatus: http.StatusOK,
+			wantType:   MimeApplicationJSON,
+			wantBody:   "\"hello\"\n",
+		},
+		{
+			name:       "encoding error",
+			accept:     "application/json",
+			data:       math.Inf(1),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			SendResponse(testutil.Context(), rr, req, http.StatusOK, tt.data)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			require.Equal(t, "Accept", rr.Header().Get("Vary"))
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			require.Equal(t, tt.wantType, rr.Header().Get("Content-Type"))
+			require.Equal(t, tt.wantBody, rr.Body.String())
+		})
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	t.Parallel()
+
+	const mediaType = "application/x-test-encoder"
+
+	RegisterEncoder(mediaType, mediaType, func(w io.Writer, data interface{}) error {
+		return errors.New("first")
+	})
+
+	// replace the encoder
+	RegisterEncoder(" Application/X-Test-Encoder ", mediaType+"; v=2", func(w io.Writer, data interface{}) error {
+		_, err := fmt.Fprintf(w, "test:%v", data)
+		return err
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/", nil)
+	req.Header.Set("Accept", mediaType)
+
+	SendResponse(testutil.Context(), rr, req, http.StatusCreated, 7)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, mediaType+"; v=2", rr.Header().Get("Content-Type"))
+	require.Equal(t, "test:7", rr.Body.String())
+
+	// the default encoder is still preferred for any type
+	rr = httptest.NewRecorder()
+	req.Header.Set("Accept", "*/*")
+
+	SendResponse(testutil.Context(), rr, req, http.StatusOK, 7)
+
+	require.Equal(t, MimeApplicationJSON, rr.Header().Get("Content-Type"))
+}
@@ -0,0 +1,230 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"github.com/nexmoinc/gosrvlib/pkg/validator"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+const (
+	// MimeApplicationProblemJSON contains the mime type string for RFC 7807 problem details.
+	MimeApplicationProblemJSON = "application/problem+json; charset=utf-8"
+
+	// ProblemTypeDefault is the default problem type, indicating that the problem has no additional semantics
+	// beyond the HTTP status code.
+	ProblemTypeDefault = "about:blank"
+
+	// ProblemExtInvalidParams is the name of the problem extension member containing the list of invalid parameters.
+	ProblemExtInvalidParams = "invalid-params"
+)
+
+// problemMembers contains the standard problem members that cannot be overridden by the extensions.
+var problemMembers = map[string]struct{}{"type": {}, "title": {}, "status": {}, "detail": {}, "instance": {}}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type (default "about:blank").
+	Type string
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+
+	// Status is the HTTP status code.
+	Status int
+
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+
+	// Instance is a URI reference that identifies the specific occurrence of the problem.
+	Instance string
+
+	// Extensions contains the additional members of the problem (e.g. "invalid-params").
+	Extensions map[string]interface{}
+}
+
+// InvalidParam describes an invalid request parameter in the "invalid-params" problem extension.
+type InvalidParam struct {
+	// Name is the parameter name.
+	Name string `json:"name"`
+
+	// Reason is the reason why the parameter is invalid.
+	Reason string `json:"reason"`
+}
+
+// NewProblem returns a new problem of the default type with the title of the HTTP status code.
+func NewProblem(statusCode int, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeDefault,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+}
+
+// NewValidationProblem returns a new problem with the "invalid-params" extension
+// containing the validator.Error list of the error returned by the validator package.
+// The other errors are reported in the problem detail.
+func NewValidationProblem(statusCode int, err error) *Problem {
+	return newValidationProblem(statusCode, err, validationParamName)
+}
+
+func newValidationProblem(statusCode int, err error, nameFn func(ve *validator.Error) string) *Problem {
+	p := NewProblem(statusCode, "")
+
+	var (
+		params  []InvalidParam
+		details []string
+	)
+
+	for _, e := range multierr.Errors(err) {
+		var ve *validator.Error
+		if !errors.As(e, &ve) {
+			details = append(details, e.Error())
+			continue
+		}
+
+		params = append(params, InvalidParam{Name: nameFn(ve), Reason: ve.Error()})
+	}
+
+	if len(details) > 0 {
+		p.Detail = strings.Join(details, "; ")
+	} else if len(params) > 0 {
+		p.Detail = "the request contains invalid parameters"
+	}
+
+	if len(params) > 0 {
+		p.WithExtension(ProblemExtInvalidParams, params)
+	}
+
+	return p
+}
+
+// validationParamName returns the field namespace without the root struct name (e.g. "address.city").
+func validationParamName(ve *validator.Error) string {
+	if ve.Namespace != "" {
+		return ve.Namespace
+	}
+
+	return ve.Field
+}
+
+// WithExtension sets an extension member and returns the problem.
+func (p *Problem) WithExtension(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+
+	p.Extensions[name] = value
+
+	return p
+}
+
+// Error returns a string representation of the problem.
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// MarshalJSON encodes the problem with the extension members at the top level.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+
+	for k, v := range p.Extensions {
+		if _, ok := problemMembers[k]; !ok {
+			m[k] = v
+		}
+	}
+
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = ProblemTypeDefault
+	}
+
+	m["title"] = p.Title
+	m["status"] = p.Status
+
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	return json.Marshal(m) //nolint:wrapcheck
+}
+
+// UnmarshalJSON decodes the problem, collecting the non-standard members into the extensions.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var std struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail"`
+		Instance string `json:"instance"`
+	}
+
+	if err := json.Unmarshal(data, &std); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	*p = Problem{
+		Type:     std.Type,
+		Title:    std.Title,
+		Status:   std.Status,
+		Detail:   std.Detail,
+		Instance: std.Instance,
+	}
+
+	for k, v := range members {
+		if _, ok := problemMembers[k]; ok {
+			continue
+		}
+
+		var ext interface{}
+		if err := json.Unmarshal(v, &ext); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		p.WithExtension(k, ext)
+	}
+
+	return nil
+}
+
+// SendProblem sends an RFC 7807 problem details object with the application/problem+json content type.
+// The problem status is set to 500 Internal Server Error when it is not a valid HTTP status code.
+func SendProblem(ctx context.Context, w http.ResponseWriter, p *Problem) {
+	if p.Status < 100 || p.Status > 999 {
+		p.Status = http.StatusInternalServerError
+	}
+
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	defer logResponse(ctx, p.Status, logKeyResponseDataObject, p)
+
+	writeHeaders(w, p.Status, MimeApplicationProblemJSON)
+
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logging.FromContext(ctx).Error("httputil.SendProblem()", zap.Error(err))
+	}
+}
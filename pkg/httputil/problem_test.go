@@ -0,0 +1,125 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/nexmoinc/gosrvlib/pkg/validator"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+func TestNewProblem(t *testing.T) {
+	t.Parallel()
+
+	p := NewProblem(http.StatusNotFound, "missing")
+	require.Equal(t, &Problem{Type: ProblemTypeDefault, Title: "Not Found", Status: http.StatusNotFound, Detail: "missing"}, p)
+	require.Equal(t, "404 Not Found: missing", p.Error())
+
+	p = NewProblem(http.StatusConflict, "")
+	require.Equal(t, "409 Conflict", p.Error())
+}
+
+func TestNewValidationProblem(t *testing.T) {
+	t.Parallel()
+
+	type Address struct {
+		City string `json:"city" validate:"required"`
+	}
+
+	type User struct {
+		Name    string  `json:"name" validate:"required"`
+		Age     int     `json:"age" validate:"min=18"`
+		Address Address `json:"address"`
+	}
+
+	v, err := validator.New(validator.WithFieldNameTag("json"), validator.WithErrorTemplates(validator.ErrorTemplates()))
+	require.NoError(t, err)
+
+	verr := v.ValidateStruct(User{Age: 10})
+	require.Error(t, verr)
+
+	p := NewValidationProblem(http.StatusUnprocessableEntity, verr)
+	require.Equal(t, http.StatusUnprocessableEntity, p.Status)
+	require.Equal(t, "the request contains invalid parameters", p.Detail)
+
+	params, ok := p.Extensions[ProblemExtInvalidParams].([]InvalidParam)
+	require.True(t, ok)
+	require.Len(t, params, 3)
+	require.Equal(t, "name", params[0].Name)
+	require.Equal(t, "age", params[1].Name)
+	require.Equal(t, "address.city", params[2].Name)
+	require.NotEmpty(t, params[0].Reason)
+
+	// other errors
+	p = NewValidationProblem(http.StatusBadRequest, multierr.Combine(errors.New("first"), &validator.Error{Field: "field", Err: "invalid"}, errors.New("second")))
+	require.Equal(t, "first; second", p.Detail)
+	require.Equal(t, []InvalidParam{{Name: "field", Reason: "invalid"}}, p.Extensions[ProblemExtInvalidParams])
+
+	p = NewValidationProblem(http.StatusBadRequest, nil)
+	require.Empty(t, p.Detail)
+	require.Nil(t, p.Extensions)
+}
+
+func TestProblem_JSON(t *testing.T) {
+	t.Parallel()
+
+	p := &Problem{
+		Title:    "Out of credit",
+		Status:   http.StatusForbidden,
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]interface{}{
+			"balance": 30,
+			"status":  "ignored",
+		},
+	}
+
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"about:blank","title":"Out of credit","status":403,"instance":"/account/12345/msgs/abc","balance":30}`, string(b))
+
+	var got Problem
+
+	require.NoError(t, json.Unmarshal(b, &got))
+	require.Equal(t, Problem{
+		Type:       ProblemTypeDefault,
+		Title:      "Out of credit",
+		Status:     http.StatusForbidden,
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]interface{}{"balance": float64(30)},
+	}, got)
+
+	require.Error(t, json.Unmarshal([]byte(`{"status":"invalid"}`), &got))
+	require.Error(t, json.Unmarshal([]byte(`[]`), &got))
+}
+
+func TestSendProblem(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	SendProblem(testutil.Context(), rr, NewProblem(http.StatusBadRequest, "invalid").WithExtension("code", "E1"))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, MimeApplicationProblemJSON, rr.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid","code":"E1"}`, rr.Body.String())
+
+	// invalid status
+	rr = httptest.NewRecorder()
+	SendProblem(testutil.Context(), rr, &Problem{})
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500}`, rr.Body.String())
+
+	// test error condition
+	mockWriter := NewMockTestHTTPResponseWriter(gomock.NewController(t))
+	mockWriter.EXPECT().Header().AnyTimes().Return(http.Header{})
+	mockWriter.EXPECT().WriteHeader(http.StatusOK)
+	mockWriter.EXPECT().Write(gomock.Any()).Return(0, fmt.Errorf("io error"))
+	SendProblem(testutil.Context(), mockWriter, NewProblem(http.StatusOK, ""))
+}
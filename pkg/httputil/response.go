@@ -41,17 +41,24 @@ type Status int
 
 // MarshalJSON implements the custom marshaling function for the json encoder.
 func (sc Status) MarshalJSON() ([]byte, error) {
-	s := StatusSuccess
+	return json.Marshal(sc.jsendStatus()) //nolint:wrapcheck
+}
 
-	if sc >= http.StatusBadRequest { // 400+
-		s = StatusFail
-	}
+// MarshalText implements the custom marshaling function for the text based encoders (e.g. XML).
+func (sc Status) MarshalText() ([]byte, error) {
+	return []byte(sc.jsendStatus()), nil
+}
 
+func (sc Status) jsendStatus() string {
 	if sc >= http.StatusInternalServerError { // 500+
-		s = StatusError
+		return StatusError
+	}
+
+	if sc >= http.StatusBadRequest { // 400+
+		return StatusFail
 	}
 
-	return json.Marshal(s) //nolint:wrapcheck
+	return StatusSuccess
 }
 
 // SendStatus sends write a HTTP status code to the response.
@@ -56,6 +56,16 @@ func TestStatus_MarshalJSON(t *testing.T) {
 	}
 }
 
+func TestStatus_MarshalText(t *testing.T) {
+	t.Parallel()
+
+	for code, want := range map[int]string{200: StatusSuccess, 404: StatusFail, 503: StatusError} {
+		got, err := Status(code).MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, want, string(got))
+	}
+}
+
 func TestSendStatus(t *testing.T) {
 	t.Parallel()
 
@@ -2,15 +2,147 @@ package httputil
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"time"
+)
+
+// Bit flags of the optional interfaces implemented by the wrapped http.ResponseWriter.
+const (
+	rwFlusher = 1 << iota
+	rwHijacker
+	rwPusher
+	rwReaderFrom
 )
 
 // NewResponseWriterWrapper wraps an http.ResponseWriter with an enhanced proxy.
+// The returned value implements the same optional interfaces (http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom)
+// implemented by the wrapped writer, so the type assertions on the wrapper behave as on the original writer.
+//
+//nolint:gocyclo
 func NewResponseWriterWrapper(w http.ResponseWriter) ResponseWriterWrapper {
-	return &responseWriterWrapper{ResponseWriter: w}
+	b := &responseWriterWrapper{ResponseWriter: w, start: time.Now()}
+
+	var flags int
+
+	if _, ok := w.(http.Flusher); ok {
+		flags |= rwFlusher
+	}
+
+	if _, ok := w.(http.Hijacker); ok {
+		flags |= rwHijacker
+	}
+
+	if _, ok := w.(http.Pusher); ok {
+		flags |= rwPusher
+	}
+
+	if _, ok := w.(io.ReaderFrom); ok {
+		flags |= rwReaderFrom
+	}
+
+	f := flusher{b}
+	h := hijacker{b}
+	p := pusher{b}
+	rf := readerFrom{b}
+
+	switch flags {
+	case rwFlusher:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+		}{b, f}
+	case rwHijacker:
+		return struct {
+			*responseWriterWrapper
+			http.Hijacker
+		}{b, h}
+	case rwFlusher | rwHijacker:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Hijacker
+		}{b, f, h}
+	case rwPusher:
+		return struct {
+			*responseWriterWrapper
+			http.Pusher
+		}{b, p}
+	case rwFlusher | rwPusher:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Pusher
+		}{b, f, p}
+	case rwHijacker | rwPusher:
+		return struct {
+			*responseWriterWrapper
+			http.Hijacker
+			http.Pusher
+		}{b, h, p}
+	case rwFlusher | rwHijacker | rwPusher:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{b, f, h, p}
+	case rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			io.ReaderFrom
+		}{b, rf}
+	case rwFlusher | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			io.ReaderFrom
+		}{b, f, rf}
+	case rwHijacker | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Hijacker
+			io.ReaderFrom
+		}{b, h, rf}
+	case rwFlusher | rwHijacker | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{b, f, h, rf}
+	case rwPusher | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Pusher
+			io.ReaderFrom
+		}{b, p, rf}
+	case rwFlusher | rwPusher | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{b, f, p, rf}
+	case rwHijacker | rwPusher | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{b, h, p, rf}
+	case rwFlusher | rwHijacker | rwPusher | rwReaderFrom:
+		return struct {
+			*responseWriterWrapper
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{b, f, h, p, rf}
+	}
+
+	return b
 }
 
 // ResponseWriterWrapper is the interface defining the extendend functions of the proxy.
@@ -25,6 +157,13 @@ type ResponseWriterWrapper interface {
 
 	// Tee sets a writer that will contain a copy of the bytes written to the response writer.
 	Tee(io.Writer)
+
+	// TimeToFirstByte returns the time elapsed between the creation of the wrapper and the sending of the response headers,
+	// or zero if the headers have not been sent yet.
+	TimeToFirstByte() time.Duration
+
+	// Unwrap returns the wrapped http.ResponseWriter.
+	Unwrap() http.ResponseWriter
 }
 
 type responseWriterWrapper struct {
@@ -33,64 +172,14 @@ type responseWriterWrapper struct {
 	size          int
 	status        int
 	tee           io.Writer
+	start         time.Time
+	ttfb          time.Duration
 }
 
 func (b *responseWriterWrapper) Size() int {
 	return b.size
 }
 
-func (b *responseWriterWrapper) Flush() {
-	b.headerWritten = true
-
-	fl, ok := b.ResponseWriter.(http.Flusher)
-	if ok {
-		fl.Flush()
-	}
-}
-
-//nolint:wrapcheck
-func (b *responseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	hj, ok := b.ResponseWriter.(http.Hijacker)
-	if !ok {
-		return nil, nil, fmt.Errorf("the Hijacker is not supported by the ResponseWriter")
-	}
-
-	return hj.Hijack()
-}
-
-//nolint:wrapcheck
-func (b *responseWriterWrapper) Push(target string, opts *http.PushOptions) error {
-	pusher, ok := b.ResponseWriter.(http.Pusher)
-	if !ok {
-		return fmt.Errorf("the Pusher is not supported by the ResponseWriter")
-	}
-
-	return pusher.Push(target, opts)
-}
-
-//nolint:wrapcheck
-func (b *responseWriterWrapper) ReadFrom(r io.Reader) (int64, error) {
-	if b.tee != nil {
-		n, err := io.Copy(b, r)
-		b.size += int(n)
-
-		return n, err
-	}
-
-	rf, ok := b.ResponseWriter.(io.ReaderFrom)
-	if !ok {
-		return 0, fmt.Errorf("the ReaderFrom is not supported by the ResponseWriter")
-	}
-
-	b.maybeWriteHeader()
-
-	n, err := rf.ReadFrom(r)
-
-	b.size += int(n)
-
-	return n, err
-}
-
 func (b *responseWriterWrapper) Status() int {
 	return b.status
 }
@@ -99,6 +188,14 @@ func (b *responseWriterWrapper) Tee(w io.Writer) {
 	b.tee = w
 }
 
+func (b *responseWriterWrapper) TimeToFirstByte() time.Duration {
+	return b.ttfb
+}
+
+func (b *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
 func (b *responseWriterWrapper) Write(buf []byte) (int, error) {
 	b.maybeWriteHeader()
 	n, err := b.ResponseWriter.Write(buf)
@@ -117,11 +214,25 @@ func (b *responseWriterWrapper) Write(buf []byte) (int, error) {
 }
 
 func (b *responseWriterWrapper) WriteHeader(code int) {
-	if !b.headerWritten {
-		b.status = code
-		b.headerWritten = true
+	if b.headerWritten {
+		return
+	}
+
+	// the informational 1xx headers (e.g. 103 Early Hints) precede the final response status
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
 		b.ResponseWriter.WriteHeader(code)
+		return
 	}
+
+	b.recordHeader(code)
+	b.ResponseWriter.WriteHeader(code)
+}
+
+// recordHeader records the status code and the time to first byte of the response.
+func (b *responseWriterWrapper) recordHeader(code int) {
+	b.status = code
+	b.headerWritten = true
+	b.ttfb = time.Since(b.start)
 }
 
 func (b *responseWriterWrapper) maybeWriteHeader() {
@@ -129,3 +240,62 @@ func (b *responseWriterWrapper) maybeWriteHeader() {
 		b.WriteHeader(http.StatusOK)
 	}
 }
+
+// flusher implements http.Flusher for the wrapped writers implementing it.
+type flusher struct {
+	*responseWriterWrapper
+}
+
+func (b flusher) Flush() {
+	b.maybeWriteHeader()
+	b.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker implements http.Hijacker for the wrapped writers implementing it.
+type hijacker struct {
+	*responseWriterWrapper
+}
+
+// Hijack takes over the connection, recording the 101 Switching Protocols status
+// if the response headers have not been sent yet (e.g. WebSocket).
+//
+//nolint:wrapcheck
+func (b hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := b.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !b.headerWritten {
+		b.recordHeader(http.StatusSwitchingProtocols)
+	}
+
+	return conn, rw, err
+}
+
+// pusher implements http.Pusher for the wrapped writers implementing it.
+type pusher struct {
+	*responseWriterWrapper
+}
+
+//nolint:wrapcheck
+func (b pusher) Push(target string, opts *http.PushOptions) error {
+	return b.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// readerFrom implements io.ReaderFrom for the wrapped writers implementing it.
+type readerFrom struct {
+	*responseWriterWrapper
+}
+
+//nolint:wrapcheck
+func (b readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	if b.tee != nil {
+		// copy through Write to duplicate the data in the tee writer
+		return io.Copy(b.responseWriterWrapper, r)
+	}
+
+	b.maybeWriteHeader()
+
+	n, err := b.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+
+	b.size += int(n)
+
+	return n, err
+}
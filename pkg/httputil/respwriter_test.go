@@ -3,11 +3,13 @@ package httputil
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +18,7 @@ type mockResponseWriter struct {
 	*bytes.Buffer
 	hijackCalled bool
 	pushCalled   bool
+	codes        []int
 }
 
 func newMockResponseWriter() *mockResponseWriter {
@@ -33,7 +36,7 @@ func (rw *mockResponseWriter) Write(in []byte) (int, error) {
 }
 
 func (rw *mockResponseWriter) WriteHeader(statusCode int) {
-
+	rw.codes = append(rw.codes, statusCode)
 }
 
 func (rw *mockResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
@@ -65,15 +68,193 @@ func (rw *mockBrokenResponseWriter) WriteHeader(statusCode int) {
 
 }
 
+// testOptionalWriter implements the optional interfaces on behalf of the test writers.
+type testOptionalWriter struct {
+	*mockResponseWriter
+	flushCalled    bool
+	readFromCalled bool
+}
+
+func (rw *testOptionalWriter) Flush() {
+	rw.flushCalled = true
+}
+
+//nolint:wrapcheck
+func (rw *testOptionalWriter) ReadFrom(r io.Reader) (int64, error) {
+	rw.readFromCalled = true
+	return rw.Buffer.ReadFrom(r)
+}
+
+// newTestWriter returns an http.ResponseWriter implementing only the optional interfaces selected by the flags.
+//
+//nolint:gocyclo
+func newTestWriter(flags int) (http.ResponseWriter, *testOptionalWriter) {
+	o := &testOptionalWriter{mockResponseWriter: newMockResponseWriter()}
+
+	var (
+		w  http.ResponseWriter = newMockBrokenResponseWriter()
+		f  http.Flusher        = o
+		h  http.Hijacker       = o
+		p  http.Pusher         = o
+		rf io.ReaderFrom       = o
+	)
+
+	type rw = http.ResponseWriter
+
+	switch flags {
+	case rwFlusher:
+		return struct {
+			rw
+			http.Flusher
+		}{o, f}, o
+	case rwHijacker:
+		return struct {
+			rw
+			http.Hijacker
+		}{o, h}, o
+	case rwFlusher | rwHijacker:
+		return struct {
+			rw
+			http.Flusher
+			http.Hijacker
+		}{o, f, h}, o
+	case rwPusher:
+		return struct {
+			rw
+			http.Pusher
+		}{o, p}, o
+	case rwFlusher | rwPusher:
+		return struct {
+			rw
+			http.Flusher
+			http.Pusher
+		}{o, f, p}, o
+	case rwHijacker | rwPusher:
+		return struct {
+			rw
+			http.Hijacker
+			http.Pusher
+		}{o, h, p}, o
+	case rwFlusher | rwHijacker | rwPusher:
+		return struct {
+			rw
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{o, f, h, p}, o
+	case rwReaderFrom:
+		return struct {
+			rw
+			io.ReaderFrom
+		}{o, rf}, o
+	case rwFlusher | rwReaderFrom:
+		return struct {
+			rw
+			http.Flusher
+			io.ReaderFrom
+		}{o, f, rf}, o
+	case rwHijacker | rwReaderFrom:
+		return struct {
+			rw
+			http.Hijacker
+			io.ReaderFrom
+		}{o, h, rf}, o
+	case rwFlusher | rwHijacker | rwReaderFrom:
+		return struct {
+			rw
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{o, f, h, rf}, o
+	case rwPusher | rwReaderFrom:
+		return struct {
+			rw
+			http.Pusher
+			io.ReaderFrom
+		}{o, p, rf}, o
+	case rwFlusher | rwPusher | rwReaderFrom:
+		return struct {
+			rw
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{o, f, p, rf}, o
+	case rwHijacker | rwPusher | rwReaderFrom:
+		return struct {
+			rw
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{o, h, p, rf}, o
+	case rwFlusher | rwHijacker | rwPusher | rwReaderFrom:
+		return struct {
+			rw
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{o, f, h, p, rf}, o
+	}
+
+	return struct{ rw }{w}, o
+}
+
 func TestNewWrapResponseWriter(t *testing.T) {
 	t.Parallel()
 
 	rr := httptest.NewRecorder()
 	ww := NewResponseWriterWrapper(rr)
 	require.NotNil(t, ww)
-	wwResponseWriterWrapper, ok := ww.(*responseWriterWrapper)
+	require.Equal(t, reflect.ValueOf(rr).Pointer(), reflect.ValueOf(ww.Unwrap()).Pointer())
+
+	_, ok := ww.(http.Flusher)
 	require.True(t, ok)
-	require.Equal(t, reflect.ValueOf(rr).Pointer(), reflect.ValueOf(wwResponseWriterWrapper.ResponseWriter).Pointer())
+}
+
+func TestNewWrapResponseWriter_optionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	for flags := 0; flags <= rwFlusher|rwHijacker|rwPusher|rwReaderFrom; flags++ {
+		w, o := newTestWriter(flags)
+		ww := NewResponseWriterWrapper(w)
+
+		fl, ok := ww.(http.Flusher)
+		require.Equal(t, flags&rwFlusher != 0, ok, "flags %04b: http.Flusher", flags)
+
+		if ok {
+			fl.Flush()
+			require.True(t, o.flushCalled)
+			require.Equal(t, http.StatusOK, ww.Status())
+		}
+
+		hj, ok := ww.(http.Hijacker)
+		require.Equal(t, flags&rwHijacker != 0, ok, "flags %04b: http.Hijacker", flags)
+
+		if ok {
+			_, _, err := hj.Hijack()
+			require.NoError(t, err)
+			require.True(t, o.hijackCalled)
+		}
+
+		ps, ok := ww.(http.Pusher)
+		require.Equal(t, flags&rwPusher != 0, ok, "flags %04b: http.Pusher", flags)
+
+		if ok {
+			require.NoError(t, ps.Push("/", nil))
+			require.True(t, o.pushCalled)
+		}
+
+		rf, ok := ww.(io.ReaderFrom)
+		require.Equal(t, flags&rwReaderFrom != 0, ok, "flags %04b: io.ReaderFrom", flags)
+
+		if ok {
+			n, err := rf.ReadFrom(bytes.NewBufferString("0123456789"))
+			require.NoError(t, err)
+			require.Equal(t, int64(10), n)
+			require.True(t, o.readFromCalled)
+			require.Equal(t, 10, ww.Size())
+		}
+	}
 }
 
 func Test_responseWriterWrapper_Size(t *testing.T) {
@@ -90,9 +271,12 @@ func Test_responseWriterWrapper_Size(t *testing.T) {
 func Test_responseWriterWrapper_Flush(t *testing.T) {
 	t.Parallel()
 
-	ww := responseWriterWrapper{ResponseWriter: httptest.NewRecorder()}
-	ww.Flush()
-	require.True(t, ww.headerWritten, "expected flush to set headerWritten=true")
+	rr := httptest.NewRecorder()
+	ww := NewResponseWriterWrapper(rr)
+	ww.(http.Flusher).Flush()
+	require.True(t, rr.Flushed)
+	require.Equal(t, http.StatusOK, ww.Status())
+	require.Positive(t, ww.TimeToFirstByte())
 }
 
 func Test_responseWriterWrapper_Status(t *testing.T) {
@@ -121,6 +305,25 @@ func Test_responseWriterWrapper_Tee(t *testing.T) {
 	require.Equal(t, "tee", buf.String())
 }
 
+func Test_responseWriterWrapper_TimeToFirstByte(t *testing.T) {
+	t.Parallel()
+
+	ww := NewResponseWriterWrapper(httptest.NewRecorder())
+	require.Zero(t, ww.TimeToFirstByte())
+
+	time.Sleep(10 * time.Millisecond)
+
+	_, err := ww.Write([]byte("first"))
+	require.NoError(t, err)
+
+	ttfb := ww.TimeToFirstByte()
+	require.GreaterOrEqual(t, ttfb, 10*time.Millisecond)
+
+	_, err = ww.Write([]byte("second"))
+	require.NoError(t, err)
+	require.Equal(t, ttfb, ww.TimeToFirstByte())
+}
+
 func Test_responseWriterWrapper_Write(t *testing.T) {
 	t.Parallel()
 
@@ -141,6 +344,22 @@ func Test_responseWriterWrapper_WriteHeader(t *testing.T) {
 	require.Equal(t, 204, ww.Status())
 }
 
+func Test_responseWriterWrapper_WriteHeader_informational(t *testing.T) {
+	t.Parallel()
+
+	mock := newMockResponseWriter()
+	ww := NewResponseWriterWrapper(mock)
+
+	ww.WriteHeader(http.StatusEarlyHints)
+	require.Zero(t, ww.Status())
+	require.Zero(t, ww.TimeToFirstByte())
+
+	ww.WriteHeader(http.StatusCreated)
+	require.Equal(t, http.StatusCreated, ww.Status())
+	require.NotZero(t, ww.TimeToFirstByte())
+	require.Equal(t, []int{http.StatusEarlyHints, http.StatusCreated}, mock.codes)
+}
+
 func Test_responseWriterWrapper_Hijack(t *testing.T) {
 	t.Parallel()
 
@@ -148,12 +367,16 @@ func Test_responseWriterWrapper_Hijack(t *testing.T) {
 	ww := NewResponseWriterWrapper(mock)
 	require.NotNil(t, ww)
 
-	wwResponseWriterWrapper, ok := ww.(*responseWriterWrapper)
+	hj, ok := ww.(http.Hijacker)
 	require.True(t, ok)
 
-	_, _, err := wwResponseWriterWrapper.Hijack()
+	time.Sleep(time.Millisecond)
+
+	_, _, err := hj.Hijack()
 	require.NoError(t, err)
 	require.True(t, mock.hijackCalled)
+	require.Equal(t, http.StatusSwitchingProtocols, ww.Status())
+	require.GreaterOrEqual(t, ww.TimeToFirstByte(), time.Millisecond)
 }
 
 func Test_broken_responseWriterWrapper_Hijack(t *testing.T) {
@@ -163,11 +386,8 @@ func Test_broken_responseWriterWrapper_Hijack(t *testing.T) {
 	ww := NewResponseWriterWrapper(mock)
 	require.NotNil(t, ww)
 
-	wwResponseWriterWrapper, ok := ww.(*responseWriterWrapper)
-	require.True(t, ok)
-
-	_, _, err := wwResponseWriterWrapper.Hijack()
-	require.Error(t, err)
+	_, ok := ww.(http.Hijacker)
+	require.False(t, ok)
 }
 
 func Test_responseWriterWrapper_Push(t *testing.T) {
@@ -177,10 +397,10 @@ func Test_responseWriterWrapper_Push(t *testing.T) {
 	ww := NewResponseWriterWrapper(mock)
 	require.NotNil(t, ww)
 
-	wwResponseWriterWrapper, ok := ww.(*responseWriterWrapper)
+	ps, ok := ww.(http.Pusher)
 	require.True(t, ok)
 
-	_ = wwResponseWriterWrapper.Push("", &http.PushOptions{})
+	_ = ps.Push("", &http.PushOptions{})
 
 	require.True(t, mock.pushCalled)
 }
@@ -192,11 +412,8 @@ func Test_broken_responseWriterWrapper_Push(t *testing.T) {
 	ww := NewResponseWriterWrapper(mock)
 	require.NotNil(t, ww)
 
-	wwResponseWriterWrapper, ok := ww.(*responseWriterWrapper)
-	require.True(t, ok)
-
-	err := wwResponseWriterWrapper.Push("", &http.PushOptions{})
-	require.Error(t, err)
+	_, ok := ww.(http.Pusher)
+	require.False(t, ok)
 }
 
 func Test_responseWriterWrapper_ReadFrom(t *testing.T) {
@@ -208,9 +425,11 @@ func Test_responseWriterWrapper_ReadFrom(t *testing.T) {
 	require.NotNil(t, ww)
 
 	inputBuf := bytes.NewBufferString("0123456789")
-	count, err := ww.(*responseWriterWrapper).ReadFrom(inputBuf)
+	count, err := ww.(io.ReaderFrom).ReadFrom(inputBuf)
 	require.NoError(t, err)
 	require.Equal(t, int64(10), count)
+	require.Equal(t, 10, ww.Size())
+	require.Equal(t, http.StatusOK, ww.Status())
 
 	// with tee writer
 	mockTee := newMockResponseWriter()
@@ -221,14 +440,13 @@ func Test_responseWriterWrapper_ReadFrom(t *testing.T) {
 	wwTee.Tee(teeBuf)
 
 	inputBufTee := bytes.NewBufferString("0123456789")
-	countTee, err := wwTee.(*responseWriterWrapper).ReadFrom(inputBufTee)
+	countTee, err := wwTee.(io.ReaderFrom).ReadFrom(inputBufTee)
 	require.NoError(t, err)
 	require.Equal(t, int64(10), countTee)
 	require.Equal(t, "0123456789", teeBuf.String())
-
-	wwTeeResponseWriterWrapper, ok := wwTee.(*responseWriterWrapper)
-	require.True(t, ok)
-	require.True(t, wwTeeResponseWriterWrapper.headerWritten)
+	require.Equal(t, "0123456789", mockTee.String())
+	require.Equal(t, 10, wwTee.Size())
+	require.Equal(t, http.StatusOK, wwTee.Status())
 }
 
 func Test_broken_responseWriterWrapper_ReadFrom(t *testing.T) {
@@ -238,7 +456,6 @@ func Test_broken_responseWriterWrapper_ReadFrom(t *testing.T) {
 	ww := NewResponseWriterWrapper(mock)
 	require.NotNil(t, ww)
 
-	inputBuf := bytes.NewBufferString("-")
-	_, err := ww.(*responseWriterWrapper).ReadFrom(inputBuf)
-	require.Error(t, err)
+	_, ok := ww.(io.ReaderFrom)
+	require.False(t, ok)
 }
@@ -0,0 +1,244 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	// MimeTextEventStream contains the mime type string for Server-Sent Events.
+	MimeTextEventStream = "text/event-stream"
+
+	// DefaultSSEHeartbeatInterval is the default interval between the heartbeat comments
+	// sent to keep the idle connections open.
+	DefaultSSEHeartbeatInterval = 15 * time.Second
+)
+
+// SSEEvent is a Server-Sent Event.
+type SSEEvent struct {
+	// ID is the optional event ID, sent back by the client in the Last-Event-ID header when reconnecting.
+	ID string
+
+	// Event is the optional event type.
+	Event string
+
+	// Data is the event data.
+	// Strings and byte slices are sent as they are, while the other values are encoded as JSON.
+	Data interface{}
+}
+
+// SSEConfig contains the Server-Sent Events stream settings.
+type SSEConfig struct {
+	// HeartbeatInterval is the interval between the heartbeat comments.
+	// A zero value sets DefaultSSEHeartbeatInterval, while a negative value disables the heartbeats.
+	HeartbeatInterval time.Duration
+
+	// MaxDuration is the maximum duration of the stream, after which the stream context is canceled
+	// and the client reconnects sending the Last-Event-ID header.
+	// A zero value disables the limit.
+	MaxDuration time.Duration
+
+	// Retry is the optional reconnection time sent to the client.
+	Retry time.Duration
+}
+
+// SSEStreamFunc is the type of function that sends the events of a stream.
+// The stream is closed when the function returns.
+// The context is canceled when the client disconnects or the stream maximum duration expires.
+type SSEStreamFunc func(ctx context.Context, stream *SSEStream) error
+
+// SSEStream sends the Server-Sent Events to the client.
+type SSEStream struct {
+	w           http.ResponseWriter
+	flusher     http.Flusher
+	lastEventID string
+	mu          sync.Mutex
+	events      int
+}
+
+// SSEHandler returns a handler that streams the Server-Sent Events sent by the specified function.
+// The response headers are set to disable caching and proxy buffering,
+// and heartbeat comments are periodically sent to keep the connection open.
+// The request logger and trace ID are available in the stream context.
+// The route must have a negative timeout when served by the httpserver package (see route.Route.Timeout),
+// otherwise the stream is buffered and then interrupted by the server WriteTimeout.
+func SSEHandler(cfg SSEConfig, fn SSEStreamFunc) http.HandlerFunc {
+	if cfg.HeartbeatInterval == 0 {
+		cfg.HeartbeatInterval = DefaultSSEHeartbeatInterval
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			logging.FromContext(ctx).Error("the response writer does not support flushing")
+			SendStatus(ctx, w, http.StatusInternalServerError)
+
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if cfg.MaxDuration > 0 {
+			ctx, cancel = context.WithTimeout(ctx, cfg.MaxDuration)
+			defer cancel()
+		}
+
+		s := &SSEStream{
+			w:           w,
+			flusher:     flusher,
+			lastEventID: r.Header.Get("Last-Event-ID"),
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", MimeTextEventStream)
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if cfg.Retry > 0 {
+			_ = s.write("retry: " + strconv.FormatInt(cfg.Retry.Milliseconds(), 10) + "\n\n")
+		} else {
+			s.flush()
+		}
+
+		var wg sync.WaitGroup
+
+		if cfg.HeartbeatInterval > 0 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				s.heartbeat(ctx, cfg.HeartbeatInterval)
+			}()
+		}
+
+		err := fn(ctx, s)
+
+		cancel()
+		wg.Wait()
+
+		l := logging.FromContext(ctx).With(zap.Int("sse_events", s.Events()))
+
+		if err != nil {
+			l.Error("SSE stream failed", zap.Error(err))
+			return
+		}
+
+		l.Debug("SSE stream closed")
+	}
+}
+
+// LastEventID returns the ID of the last event received by the client before reconnecting, if any.
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Events returns the number of events sent.
+func (s *SSEStream) Events() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events
+}
+
+// Send sends an event to the client.
+func (s *SSEStream) Send(ev SSEEvent) error {
+	if strings.ContainsAny(ev.ID, "\r\n") || strings.ContainsAny(ev.Event, "\r\n") {
+		return fmt.Errorf("the SSE event ID and type cannot contain line breaks")
+	}
+
+	var data []byte
+
+	switch v := ev.Data.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+
+		data, err = json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed encoding the SSE event data: %w", err)
+		}
+	}
+
+	var b strings.Builder
+
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+
+	if ev.Event != "" {
+		b.WriteString("event: " + ev.Event + "\n")
+	}
+
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	if err := s.write(b.String()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.events++
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *SSEStream) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write(":\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *SSEStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("failed writing the SSE stream: %w", err)
+	}
+
+	s.flusher.Flush()
+
+	return nil
+}
+
+func (s *SSEStream) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flusher.Flush()
+}
@@ -0,0 +1,119 @@
+package httputil
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type noFlushResponseWriter struct {
+	http.ResponseWriter
+}
+
+func TestSSEHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := SSEHandler(SSEConfig{HeartbeatInterval: -1, Retry: 3 * time.Second}, func(ctx context.Context, s *SSEStream) error {
+		require.Equal(t, "41", s.LastEventID())
+		require.NoError(t, s.Send(SSEEvent{ID: "42", Event: "status", Data: map[string]string{"status": "ok"}}))
+		require.NoError(t, s.Send(SSEEvent{Data: "line1\r\nline2"}))
+		require.NoError(t, s.Send(SSEEvent{Data: []byte("raw")}))
+		require.Error(t, s.Send(SSEEvent{ID: "4\n2"}))
+		require.Error(t, s.Send(SSEEvent{Event: "a\rb"}))
+		require.Error(t, s.Send(SSEEvent{Data: math.Inf(1)}))
+		require.Equal(t, 3, s.Events())
+
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, rr.Flushed)
+	require.Equal(t, MimeTextEventStream, rr.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	require.Equal(t, "no", rr.Header().Get("X-Accel-Buffering"))
+
+	exp := "retry: 3000\n\n" +
+		"id: 42\nevent: status\ndata: {\"status\":\"ok\"}\n\n" +
+		"data: line1\ndata: line2\n\n" +
+		"data: raw\n\n"
+	require.Equal(t, exp, rr.Body.String())
+}
+
+func TestSSEHandler_errors(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	handler := SSEHandler(SSEConfig{}, func(ctx context.Context, s *SSEStream) error {
+		called = true
+		return errors.New("stream error")
+	})
+
+	// flusher not supported
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(testutil.Context(), http.MethodGet, "/events", nil)
+	handler.ServeHTTP(&noFlushResponseWriter{rr}, req)
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.False(t, called)
+
+	// stream error
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, called)
+}
+
+func TestSSEHandler_server(t *testing.T) {
+	t.Parallel()
+
+	cfg := SSEConfig{
+		HeartbeatInterval: 10 * time.Millisecond,
+		MaxDuration:       100 * time.Millisecond,
+	}
+
+	handler := SSEHandler(cfg, func(ctx context.Context, s *SSEStream) error {
+		if err := s.Send(SSEEvent{ID: "1", Data: "hello"}); err != nil {
+			return err
+		}
+
+		<-ctx.Done() // max duration
+
+		return nil
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(testutil.Context(), http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	var lines []string
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+
+	require.GreaterOrEqual(t, len(lines), 5)
+	require.Equal(t, []string{"id: 1", "data: hello", ""}, lines[:3])
+	require.Contains(t, strings.Join(lines[3:], "\n"), ":\n")
+}
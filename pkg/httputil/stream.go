@@ -0,0 +1,210 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/nexmoinc/gosrvlib/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// DefaultStreamFlushItems is the default number of items written between two flushes of a stream.
+const DefaultStreamFlushItems = 100
+
+const logKeyResponseItems = "response_items"
+
+// ndjsonStreamErrorLine is the last line of an interrupted NDJSON stream.
+const ndjsonStreamErrorLine = `{"error":"stream interrupted"}` + "\n"
+
+// errStreamClosed is returned when writing to a closed stream.
+var errStreamClosed = errors.New("the stream is closed")
+
+// StreamOption is the type of function used to set the stream options.
+type StreamOption func(s *Stream)
+
+// WithStreamFlushItems sets the number of items written between two flushes.
+// A zero or negative value only flushes the stream when it is closed.
+func WithStreamFlushItems(n int) StreamOption {
+	return func(s *Stream) {
+		s.flushItems = n
+	}
+}
+
+// WithStreamEnvelope wraps the JSON array between the specified prefix and suffix
+// (e.g. `{"data":` and `}`), instead of sending a bare array.
+func WithStreamEnvelope(prefix, suffix string) StreamOption {
+	return func(s *Stream) {
+		s.prefix = prefix + s.prefix
+		s.suffix += suffix
+	}
+}
+
+// WithStreamErrorSuffix sets the data written instead of the suffix when the stream is closed with an error,
+// to signal the interruption to the client (e.g. `],"status":"error"}`).
+func WithStreamErrorSuffix(suffix string) StreamOption {
+	return func(s *Stream) {
+		s.errorSuffix = suffix
+	}
+}
+
+// Stream writes the items of a large result set to the response as they are produced,
+// without holding the whole result in memory.
+// The items are encoded as a JSON array by NewJSONStream or as JSON lines by NewNDJSONStream.
+// A Stream is not safe for concurrent use.
+type Stream struct {
+	ctx         context.Context //nolint:containedctx
+	w           http.ResponseWriter
+	flusher     http.Flusher
+	statusCode  int
+	flushItems  int
+	prefix      string
+	separator   string
+	suffix      string
+	errorSuffix string
+	items       int
+	err         error
+	closed      bool
+}
+
+// NewJSONStream sends the response headers and opens a JSON array.
+// The items are added with Write, and the array must be terminated by calling Close.
+// When the stream is closed with an error the array is left open by default,
+// so the clients fail to decode the incomplete response (see WithStreamErrorSuffix).
+func NewJSONStream(ctx context.Context, w http.ResponseWriter, statusCode int, opts ...StreamOption) *Stream {
+	s := &Stream{
+		prefix:    "[",
+		separator: ",",
+		suffix:    "]",
+	}
+
+	return s.open(ctx, w, statusCode, MimeApplicationJSON, opts)
+}
+
+// NewNDJSONStream sends the response headers for a Newline Delimited JSON stream.
+// Each item added with Write is encoded as a JSON line, and the stream must be terminated by calling Close.
+// When the stream is closed with an error the last line is {"error":"stream interrupted"} by default
+// (see WithStreamErrorSuffix).
+func NewNDJSONStream(ctx context.Context, w http.ResponseWriter, statusCode int, opts ...StreamOption) *Stream {
+	s := &Stream{
+		errorSuffix: ndjsonStreamErrorLine,
+	}
+
+	return s.open(ctx, w, statusCode, MimeApplicationNDJSON, opts)
+}
+
+func (s *Stream) open(ctx context.Context, w http.ResponseWriter, statusCode int, contentType string, opts []StreamOption) *Stream {
+	s.ctx = ctx
+	s.w = w
+	s.statusCode = statusCode
+	s.flushItems = DefaultStreamFlushItems
+	s.flusher, _ = w.(http.Flusher)
+
+	for _, applyOpt := range opts {
+		applyOpt(s)
+	}
+
+	writeHeaders(w, statusCode, contentType)
+	s.write(s.prefix)
+
+	return s
+}
+
+// Write encodes and writes an item.
+// The items that cannot be encoded are not written and the error is returned, so the stream remains valid.
+// After a write error (e.g. the client disconnected) all the following writes return the same error.
+func (s *Stream) Write(item interface{}) error {
+	if s.closed {
+		return errStreamClosed
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+
+	b, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed encoding the stream item: %w", err)
+	}
+
+	sep := s.separator
+	if s.items == 0 {
+		sep = ""
+	}
+
+	if s.separator == "" {
+		b = append(b, '\n')
+	}
+
+	s.write(sep + string(b))
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.items++
+
+	if s.flushItems > 0 && s.items%s.flushItems == 0 {
+		s.flush()
+	}
+
+	return nil
+}
+
+// Items returns the number of items written.
+func (s *Stream) Items() int {
+	return s.items
+}
+
+// Close terminates and flushes the stream, and logs the response.
+// The streamErr argument is the error that interrupted the production of the items, if any:
+// as the status code has already been sent, the error suffix is written instead of the suffix
+// to signal the interruption to the client, and the error is logged.
+// It returns the first error occurred writing the stream.
+func (s *Stream) Close(streamErr error) error {
+	if s.closed {
+		return s.err
+	}
+
+	s.closed = true
+
+	if streamErr != nil {
+		s.write(s.errorSuffix)
+	} else {
+		s.write(s.suffix)
+	}
+
+	s.flush()
+
+	l := logging.FromContext(s.ctx)
+
+	if streamErr != nil {
+		l.Error("stream interrupted", zap.Int(logKeyResponseItems, s.items), zap.Error(streamErr))
+	}
+
+	if s.err != nil {
+		l.Error("failed writing the stream", zap.Int(logKeyResponseItems, s.items), zap.Error(s.err))
+	}
+
+	logResponse(s.ctx, s.statusCode, logKeyResponseItems, s.items)
+
+	return s.err
+}
+
+func (s *Stream) write(data string) {
+	if s.err != nil || data == "" {
+		return
+	}
+
+	if _, err := s.w.Write([]byte(data)); err != nil {
+		s.err = fmt.Errorf("failed writing the stream: %w", err)
+	}
+}
+
+func (s *Stream) flush() {
+	if s.err == nil && s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
@@ -0,0 +1,141 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nexmoinc/gosrvlib/pkg/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testStreamItem struct {
+	ID int `json:"id"`
+}
+
+func TestNewJSONStream(t *testing.T) {
+	t.Parallel()
+
+	ctx, logs := testutil.ContextWithLogObserver(zap.DebugLevel)
+
+	rr := httptest.NewRecorder()
+	s := NewJSONStream(ctx, rr, http.StatusOK, WithStreamFlushItems(2))
+
+	require.Equal(t, MimeApplicationJSON, rr.Header().Get("Content-Type"))
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.Write(testStreamItem{ID: i}))
+	}
+
+	require.True(t, rr.Flushed)
+	require.Equal(t, `[{"id":1},{"id":2},{"id":3}`, rr.Body.String())
+
+	// the items that cannot be encoded are skipped
+	require.Error(t, s.Write(math.Inf(1)))
+	require.Equal(t, 3, s.Items())
+
+	require.NoError(t, s.Close(nil))
+	require.NoError(t, s.Close(nil))
+	require.Error(t, s.Write(testStreamItem{ID: 4}))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var items []testStreamItem
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &items))
+	require.Len(t, items, 3)
+
+	entries := logs.FilterField(zap.Int(logKeyResponseItems, 3)).All()
+	require.Len(t, entries, 1)
+	require.Equal(t, "Request", entries[0].Message)
+}
+
+func TestNewJSONStream_empty(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	s := NewJSONStream(testutil.Context(), rr, http.StatusOK, WithStreamEnvelope(`{"data":`, `}`))
+	require.NoError(t, s.Close(nil))
+	require.JSONEq(t, `{"data":[]}`, rr.Body.String())
+}
+
+func TestNewJSONStream_interrupted(t *testing.T) {
+	t.Parallel()
+
+	ctx, logs := testutil.ContextWithLogObserver(zap.DebugLevel)
+
+	rr := httptest.NewRecorder()
+	s := NewJSONStream(ctx, rr, http.StatusOK)
+
+	require.NoError(t, s.Write(testStreamItem{ID: 1}))
+	require.NoError(t, s.Close(errors.New("database error")))
+
+	// the array is left open to make the response invalid
+	require.Equal(t, `[{"id":1}`, rr.Body.String())
+	require.False(t, json.Valid(rr.Body.Bytes()))
+	require.Equal(t, 1, logs.FilterMessage("stream interrupted").Len())
+}
+
+func TestNewJSONStream_interruptedErrorSuffix(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	s := NewJSONStream(testutil.Context(), rr, http.StatusOK,
+		WithStreamEnvelope(`{"data":`, `}`),
+		WithStreamErrorSuffix(`],"error":"stream interrupted"}`),
+	)
+
+	require.NoError(t, s.Write(testStreamItem{ID: 1}))
+	require.NoError(t, s.Close(errors.New("database error")))
+	require.JSONEq(t, `{"data":[{"id":1}],"error":"stream interrupted"}`, rr.Body.String())
+}
+
+func TestNewJSONStream_writeError(t *testing.T) {
+	t.Parallel()
+
+	mockWriter := NewMockTestHTTPResponseWriter(gomock.NewController(t))
+	mockWriter.EXPECT().Header().AnyTimes().Return(http.Header{})
+	mockWriter.EXPECT().WriteHeader(http.StatusOK)
+	mockWriter.EXPECT().Write(gomock.Any()).Return(1, nil)
+	mockWriter.EXPECT().Write(gomock.Any()).Return(0, fmt.Errorf("io error"))
+
+	s := NewJSONStream(testutil.Context(), mockWriter, http.StatusOK)
+	require.Error(t, s.Write(testStreamItem{ID: 1}))
+	require.Error(t, s.Write(testStreamItem{ID: 2}))
+	require.Error(t, s.Close(nil))
+	require.Equal(t, 0, s.Items())
+}
+
+func TestNewNDJSONStream(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	s := NewNDJSONStream(testutil.Context(), rr, http.StatusCreated, WithStreamFlushItems(0))
+
+	require.NoError(t, s.Write(testStreamItem{ID: 1}))
+	require.NoError(t, s.Write(testStreamItem{ID: 2}))
+	require.False(t, rr.Flushed)
+	require.NoError(t, s.Close(nil))
+	require.True(t, rr.Flushed)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, MimeApplicationNDJSON, rr.Header().Get("Content-Type"))
+	require.Equal(t, "{\"id\":1}\n{\"id\":2}\n", rr.Body.String())
+}
+
+func TestNewNDJSONStream_interrupted(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	s := NewNDJSONStream(testutil.Context(), rr, http.StatusOK)
+
+	require.NoError(t, s.Write(testStreamItem{ID: 1}))
+	require.NoError(t, s.Close(errors.New("database error")))
+	require.Equal(t, "{\"id\":1}\n{\"error\":\"stream interrupted\"}\n", rr.Body.String())
+}
@@ -12,6 +12,7 @@ type config struct {
 	fields            []zap.Field
 	format            Format
 	level             zapcore.Level
+	atomicLevel       *zap.AtomicLevel
 	outputPaths       []string
 	errorOutputPaths  []string
 	incMetricLogLevel IncrementLogMetricsFunc
@@ -81,8 +81,18 @@ func NewLogger(opts ...Option) (*zap.Logger, error) {
 		hostname = ""
 	}
 
+	level := zap.NewAtomicLevelAt(cfg.level)
+	if cfg.atomicLevel != nil {
+		level = *cfg.atomicLevel
+		level.SetLevel(cfg.level)
+	}
+
+	if err := registerReopenSink(cfg.outputPaths, cfg.errorOutputPaths); err != nil {
+		return nil, err
+	}
+
 	zapCfg := zap.Config{
-		Level:    zap.NewAtomicLevelAt(cfg.level),
+		Level:    level,
 		Encoding: encoding,
 		EncoderConfig: zapcore.EncoderConfig{
 			MessageKey:   "msg",
@@ -52,6 +52,15 @@ func WithLevelStr(l string) Option {
 	}
 }
 
+// WithAtomicLevel sets an atomic level that can be used to change the logging level at runtime.
+// The atomic level is set to the configured logging level when the logger is created.
+func WithAtomicLevel(l zap.AtomicLevel) Option {
+	return func(cfg *config) error {
+		cfg.atomicLevel = &l
+		return nil
+	}
+}
+
 // WithFields add static fields to the logger.
 func WithFields(f ...zap.Field) Option {
 	return func(cfg *config) error {
@@ -28,6 +28,24 @@ func TestWithLevel(t *testing.T) {
 	require.Equal(t, v, cfg.level)
 }
 
+func TestWithAtomicLevel(t *testing.T) {
+	t.Parallel()
+
+	v := zap.NewAtomicLevel()
+	cfg := &config{}
+	err := WithAtomicLevel(v)(cfg)
+	require.Nil(t, err)
+	require.NotNil(t, cfg.atomicLevel)
+
+	l, err := NewLogger(WithAtomicLevel(v), WithLevel(zap.WarnLevel))
+	require.NoError(t, err)
+	require.Equal(t, zap.WarnLevel, v.Level())
+	require.Nil(t, l.Check(zap.InfoLevel, "info"))
+
+	v.SetLevel(zap.DebugLevel)
+	require.NotNil(t, l.Check(zap.InfoLevel, "info"))
+}
+
 func TestWithFields(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,142 @@
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// ReopenSinkScheme is the URL scheme of the log output files that can be reopened at runtime with ReopenFiles.
+// This is useful to support external log rotation tools (e.g. "reopen:///var/log/myprog.log").
+const ReopenSinkScheme = "reopen"
+
+//nolint:gochecknoglobals
+var (
+	reopenSinkOnce sync.Once
+	reopenSinkErr  error
+	reopenSinks    = &reopenSinkRegistry{sinks: make(map[*reopenSink]struct{})}
+)
+
+type reopenSinkRegistry struct {
+	mu    sync.Mutex
+	sinks map[*reopenSink]struct{}
+}
+
+// ReopenFiles closes and reopens all the log output files configured with the ReopenSinkScheme.
+func ReopenFiles() error {
+	reopenSinks.mu.Lock()
+	defer reopenSinks.mu.Unlock()
+
+	var errs error
+
+	for s := range reopenSinks.sinks {
+		errs = multierr.Append(errs, s.reopen())
+	}
+
+	return errs
+}
+
+// registerReopenSink registers the ReopenSinkScheme with zap if any output path requires it.
+func registerReopenSink(paths ...[]string) error {
+	for _, pp := range paths {
+		for _, p := range pp {
+			if strings.HasPrefix(p, ReopenSinkScheme+":") {
+				reopenSinkOnce.Do(func() {
+					reopenSinkErr = zap.RegisterSink(ReopenSinkScheme, newReopenSink)
+				})
+
+				return reopenSinkErr //nolint:wrapcheck
+			}
+		}
+	}
+
+	return nil
+}
+
+type reopenSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func newReopenSink(u *url.URL) (zap.Sink, error) {
+	path := u.Path
+	if path == "" {
+		path = u.Opaque
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("missing log file path in %q", u.String())
+	}
+
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &reopenSink{path: path, file: f}
+
+	reopenSinks.mu.Lock()
+	reopenSinks.sinks[s] = struct{}{}
+	reopenSinks.mu.Unlock()
+
+	return s, nil
+}
+
+func (s *reopenSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Write(p) //nolint:wrapcheck
+}
+
+func (s *reopenSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Sync() //nolint:wrapcheck
+}
+
+func (s *reopenSink) Close() error {
+	reopenSinks.mu.Lock()
+	delete(reopenSinks.sinks, s)
+	reopenSinks.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close() //nolint:wrapcheck
+}
+
+func (s *reopenSink) reopen() error {
+	f, err := openLogFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.file
+	s.file = f
+
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("failed closing log file %q: %w", s.path, err)
+	}
+
+	return nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed opening log file %q: %w", path, err)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReopenFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	rotated := filepath.Join(dir, "test.log.1")
+
+	l, err := NewLogger(WithOutputPaths([]string{ReopenSinkScheme + "://" + path}))
+	require.NoError(t, err)
+
+	l.Info("before rotation")
+	require.NoError(t, os.Rename(path, rotated))
+
+	l.Info("after rotation")
+	require.NoError(t, ReopenFiles())
+	l.Info("after reopen")
+
+	Sync(l)
+
+	oldData, err := os.ReadFile(rotated) //nolint:gosec
+	require.NoError(t, err)
+	require.Contains(t, string(oldData), "before rotation")
+	require.Contains(t, string(oldData), "after rotation")
+	require.NotContains(t, string(oldData), "after reopen")
+
+	newData, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	require.Contains(t, string(newData), "after reopen")
+}
+
+func TestReopenFiles_error(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewLogger(WithOutputPaths([]string{ReopenSinkScheme + ":"}))
+	require.Error(t, err)
+
+	_, err = NewLogger(WithOutputPaths([]string{ReopenSinkScheme + ":///missing-dir/test.log"}))
+	require.Error(t, err)
+}
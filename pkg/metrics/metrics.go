@@ -30,6 +30,12 @@ type Client interface {
 	Close() error
 }
 
+// GaugeSetter is an optional interface implemented by the clients supporting gauges.
+type GaugeSetter interface {
+	// SetGauge sets the current value of the gauge identified by task, operation and name.
+	SetGauge(task, operation, name string, value float64)
+}
+
 // Default is the default implementation for the Client interface.
 type Default struct{}
 
@@ -59,5 +65,8 @@ func (c *Default) IncLogLevelCounter(level string) {}
 // IncErrorCounter is an empty function.
 func (c *Default) IncErrorCounter(task, operation, code string) {}
 
+// SetGauge is an empty function.
+func (c *Default) SetGauge(task, operation, name string, value float64) {}
+
 // Close method.
 func (c *Default) Close() error { return nil }
@@ -42,10 +42,14 @@ const (
 	// NameErrorCode is the name of the collector that counts the number of errors by task, operation and error code.
 	NameErrorCode = "error_code_total"
 
+	// NameGaugeValue is the name of the collector that reports the current values by task, operation and name.
+	NameGaugeValue = "gauge_value"
+
 	labelCode      = "code"
 	labelHandler   = "handler"
 	labelLevel     = "level"
 	labelMethod    = "method"
+	labelName      = "name"
 	labelOperation = "operation"
 	labelTask      = "task"
 )
@@ -68,6 +72,7 @@ type Client struct {
 	collectorOutboundInFlightRequests prometheus.Gauge
 	collectorErrorLevel               *prometheus.CounterVec
 	collectorErrorCode                *prometheus.CounterVec
+	collectorGaugeValue               *prometheus.GaugeVec
 }
 
 // New creates a new metrics instance with default collectors.
@@ -181,6 +186,14 @@ func (c *Client) defaultCollectors() error {
 		[]string{labelTask, labelOperation, labelCode},
 	)
 
+	c.collectorGaugeValue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: NameGaugeValue,
+			Help: "Current values by task, operation and name.",
+		},
+		[]string{labelTask, labelOperation, labelName},
+	)
+
 	colls := []prometheus.Collector{
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
@@ -194,6 +207,7 @@ func (c *Client) defaultCollectors() error {
 		c.collectorOutboundInFlightRequests,
 		c.collectorErrorLevel,
 		c.collectorErrorCode,
+		c.collectorGaugeValue,
 	}
 
 	for _, m := range colls {
@@ -248,6 +262,11 @@ func (c *Client) IncErrorCounter(task, operation, code string) {
 	c.collectorErrorCode.With(prometheus.Labels{labelTask: task, labelOperation: operation, labelCode: code}).Inc()
 }
 
+// SetGauge sets the current value of the gauge identified by task, operation and name.
+func (c *Client) SetGauge(task, operation, name string, value float64) {
+	c.collectorGaugeValue.With(prometheus.Labels{labelTask: task, labelOperation: operation, labelName: name}).Set(value)
+}
+
 // Close method.
 func (c *Client) Close() error {
 	return nil
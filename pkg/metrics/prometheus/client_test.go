@@ -165,6 +165,20 @@ func TestIncErrorCounter(t *testing.T) {
 	}
 }
 
+func TestSetGauge(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	require.NoError(t, err, "unexpected error = %v", err)
+
+	c.SetGauge("test_task", "test_operation", "limit", 10)
+	c.SetGauge("test_task", "test_operation", "limit", 5)
+
+	i, err := testutil.GatherAndCount(c.registry, NameGaugeValue)
+	require.NoError(t, err, "failed to gather metrics: %s", err)
+	require.Equal(t, 1, i)
+}
+
 func TestClose(t *testing.T) {
 	t.Parallel()
 
@@ -28,6 +28,7 @@ const (
 
 	labelCount        = "count"
 	labelError        = "error"
+	labelGauge        = "gauge"
 	labelIn           = "in"
 	labelInbound      = "inbound"
 	labelLevel        = "level"
@@ -38,6 +39,7 @@ const (
 	labelResponseSize = "response_size"
 	labelSeparator    = "."
 	labelTime         = "time"
+	labelTTFB         = "ttfb"
 )
 
 // Client represents the state type of this client.
@@ -104,6 +106,11 @@ func (c *Client) InstrumentHandler(path string, handler http.HandlerFunc) http.H
 			c.statsd.Increment(labelStatus + labelCount)
 			c.statsd.Gauge(labelStatus+labelRequestSize, reqSize)
 			c.statsd.Gauge(labelStatus+labelResponseSize, rw.Size())
+
+			if ttfb := rw.TimeToFirstByte(); ttfb > 0 {
+				c.statsd.Timing(labelStatus+labelTTFB, int(ttfb/time.Millisecond))
+			}
+
 			t.Send(labelStatus + labelTime)
 		}()
 
@@ -150,6 +157,11 @@ func (c *Client) IncErrorCounter(task, operation, code string) {
 	c.statsd.Increment(labelError + labelSeparator + task + labelSeparator + operation + labelSeparator + code)
 }
 
+// SetGauge sets the current value of the gauge identified by task, operation and name.
+func (c *Client) SetGauge(task, operation, name string, value float64) {
+	c.statsd.Gauge(labelGauge+labelSeparator+task+labelSeparator+operation+labelSeparator+name, value)
+}
+
 // Close method.
 func (c *Client) Close() error {
 	c.statsd.Close()
@@ -74,6 +74,7 @@ func TestInstrumentHandler(t *testing.T) {
 TEST.inbound.test.POST.501.count:1\|c
 TEST.inbound.test.POST.501.request_size:27\|g
 TEST.inbound.test.POST.501.response_size:16\|g
+TEST.inbound.test.POST.501.ttfb:[0-9]+\|ms
 TEST.inbound.test.POST.501.time:[0-9]+\|ms
 TEST.inbound.test.POST.out:1\|c`
 		re := regexp.MustCompile(exp)
@@ -179,6 +180,15 @@ func TestIncErrorCounter(t *testing.T) {
 	c.IncErrorCounter("test_task", "test_operation", "3791")
 }
 
+func TestSetGauge(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	require.NoError(t, err, "unexpected error = %v", err)
+
+	c.SetGauge("test_task", "test_operation", "limit", 10)
+}
+
 func TestInstrumentDB(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,59 @@
+package profiling
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"time"
+)
+
+// DumpProfiles writes the stack traces of all the goroutines and a heap profile into new files
+// in the specified directory, and returns the list of created files.
+// The goroutine dump is in text format, while the heap profile can be analyzed with "go tool pprof".
+func DumpProfiles(dir string) ([]string, error) {
+	ts := strconv.FormatInt(time.Now().UTC().UnixNano(), 10)
+
+	dumps := []struct {
+		profile string
+		ext     string
+		debug   int
+	}{
+		{profile: "goroutine", ext: "txt", debug: 2},
+		{profile: "heap", ext: "pprof", debug: 0},
+	}
+
+	files := make([]string, 0, len(dumps))
+
+	for _, d := range dumps {
+		path := filepath.Join(dir, d.profile+"-"+ts+"."+d.ext)
+
+		if err := writeProfile(path, d.profile, d.debug); err != nil {
+			return files, err
+		}
+
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+func writeProfile(path, profile string, debug int) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed creating %s profile file: %w", profile, err)
+	}
+
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed closing %s profile file: %w", profile, cerr)
+		}
+	}()
+
+	if err := pprof.Lookup(profile).WriteTo(f, debug); err != nil {
+		return fmt.Errorf("failed writing %s profile: %w", profile, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package profiling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpProfiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	files, err := DumpProfiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	for _, f := range files {
+		require.Equal(t, dir, filepath.Dir(f))
+
+		fi, err := os.Stat(f)
+		require.NoError(t, err)
+		require.NotZero(t, fi.Size())
+	}
+
+	goroutines, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(goroutines), "goroutine")
+
+	_, err = DumpProfiles(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}